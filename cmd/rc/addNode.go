@@ -33,11 +33,11 @@ var addNodeCmd = &cobra.Command{
 	Short: "Make a pod join redis-cluster",
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		newPod, err := redis.NewRedisPod(args[0], containerName, namespace, redisPort, clientset, restcfg)
+		newPod, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		existingPod, err := redis.NewRedisPod(args[1], containerName, namespace, redisPort, clientset, restcfg)
+		existingPod, err := redis.NewRedisPod(ctx, args[1], containerName, namespace, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
@@ -45,7 +45,7 @@ var addNodeCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-		res, err := existingPod.ClusterAddNode(newPod, slave)
+		res, err := existingPod.ClusterAddNode(ctx, newPod, slave)
 		if err != nil {
 			return err
 		}
@@ -56,5 +56,7 @@ var addNodeCmd = &cobra.Command{
 
 func init() {
 	addNodeCmd.Flags().Bool("slave", false, "make <new pod> slave of <existing pod>")
+	audit(addNodeCmd, func(args []string) string { return args[1] })
+	addNodeCmd.ValidArgsFunction = completeRedisPods(2)
 	rootCmd.AddCommand(addNodeCmd)
 }
@@ -0,0 +1,160 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var auditLog string
+var auditEvents bool
+
+// auditOptions customizes audit of commands not only changing topology
+type auditOptions struct {
+	// targets returns pods affected by the run besides those with topology changed
+	targets func(cmd *cobra.Command, args []string, before map[string]string) []string
+	// redact hides secrets in positional args
+	redact func(args []string) []string
+}
+
+// audit records every run of a mutating command c: k8s events on affected pods and a line in audit log,
+// with cluster topology seen by entry pod before and after. entry returns "" if the run changes nothing.
+func audit(c *cobra.Command, entry func(args []string) string) {
+	auditWith(c, entry, auditOptions{})
+}
+
+func auditWith(c *cobra.Command, entry func(args []string) string, opts auditOptions) {
+	run := c.RunE
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		pod := entry(args)
+		if pod == "" {
+			return run(cmd, args)
+		}
+		recArgs := args
+		if opts.redact != nil {
+			recArgs = opts.redact(args)
+		}
+		rec := &common.AuditRecord{Time: time.Now(), User: common.KubeUser(kubeFlags), Command: cmd.CommandPath(),
+			Args: common.AuditArgs(cmd.Flags(), recArgs), Namespace: namespace}
+		before := topology(ctx, pod)
+		err := run(cmd, args)
+		rec.SetResult(err)
+
+		// ctx may be cancelled or expired when command fails
+		actx, acancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer acancel()
+		candidates := []string{pod}
+		for p := range before {
+			candidates = append(candidates, p)
+		}
+		after := topology(actx, candidates...)
+		affected := make(map[string]bool)
+		for _, a := range args {
+			if _, ok := before[a]; ok {
+				affected[a] = true
+			}
+			if _, ok := after[a]; ok {
+				affected[a] = true
+			}
+		}
+		for p, v := range before {
+			if after[p] != v {
+				affected[p] = true
+			}
+		}
+		for p, v := range after {
+			if before[p] != v {
+				affected[p] = true
+			}
+		}
+		if opts.targets != nil {
+			for _, p := range opts.targets(cmd, args, before) {
+				affected[p] = true
+			}
+		}
+		if len(affected) == 0 {
+			affected[pod] = true
+		}
+		names := make([]string, 0, len(affected))
+		for p := range affected {
+			names = append(names, p)
+		}
+		if before != nil {
+			rec.Before = before
+		}
+		if after != nil {
+			rec.After = after
+		}
+		common.NewAuditor(auditLog, auditEvents, clientset).Record(actx, rec, common.PodRefs(actx, clientset, namespace, names))
+		return err
+	}
+}
+
+// topology returns pod => "<node id> master <slots>|slave of <pod>" seen by first reachable pod in candidates
+func topology(ctx context.Context, candidates ...string) map[string]string {
+	for _, name := range candidates {
+		pod, err := redis.NewRedisPod(ctx, name, containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			klog.V(2).Infof("can't get topology from %s: %v", name, err)
+			continue
+		}
+		nodes, err := pod.ClusterNodes(ctx)
+		if err != nil {
+			klog.V(2).Infof("can't get topology from %s: %v", name, err)
+			continue
+		}
+		byID := make(map[string]*redis.RedisNode)
+		for _, n := range nodes {
+			byID[n.ID] = n
+		}
+		res := make(map[string]string)
+		for _, n := range nodes {
+			if n.IsMaster() {
+				slots := make([]string, 0, len(n.Slots))
+				for _, s := range n.Slots {
+					slots = append(slots, s.String())
+				}
+				res[n.Pod.Name] = strings.TrimSpace(fmt.Sprintf("%s master %s", n.ID, strings.Join(slots, ",")))
+			} else {
+				res[n.Pod.Name] = fmt.Sprintf("%s slave of %s", n.ID, nodeName(byID[n.MasterID]))
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+// firstArg is the entry of commands taking a cluster pod as first argument
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
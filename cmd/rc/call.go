@@ -23,6 +23,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/spf13/cobra"
 )
@@ -43,7 +44,7 @@ var callCmd = &cobra.Command{
 		}
 		for _, p := range pods {
 			fmt.Println(">>> " + p.GetName() + ":")
-			if res, err := p.Call(args[1:]...); err != nil {
+			if res, err := p.Call(ctx, args[1:]...); err != nil {
 				return err
 			} else {
 				fmt.Println(res)
@@ -53,7 +54,80 @@ var callCmd = &cobra.Command{
 	},
 }
 
+// readonlyCommands are commands never changing data, config or topology, they are not audited
+var readonlyCommands = map[string]bool{
+	"get": true, "mget": true, "strlen": true, "getrange": true, "exists": true, "type": true, "ttl": true, "pttl": true,
+	"keys": true, "scan": true, "randomkey": true, "dbsize": true, "dump": true, "object": true,
+	"hget": true, "hmget": true, "hgetall": true, "hkeys": true, "hvals": true, "hlen": true, "hexists": true, "hscan": true,
+	"llen": true, "lrange": true, "lindex": true,
+	"scard": true, "smembers": true, "sismember": true, "srandmember": true, "sscan": true,
+	"zcard": true, "zcount": true, "zrange": true, "zrangebyscore": true, "zrevrange": true, "zrevrangebyscore": true,
+	"zrank": true, "zrevrank": true, "zscore": true, "zscan": true,
+	"xlen": true, "xrange": true, "xrevrange": true, "xinfo": true, "pfcount": true, "bitcount": true, "getbit": true,
+	"info": true, "ping": true, "echo": true, "time": true, "lastsave": true, "role": true, "command": true,
+	"slowlog": true, "latency": true, "memory": true, "readonly": true, "readwrite": true,
+}
+
+// readonlySubcommands are read only subcommands of commands which can also write
+var readonlySubcommands = map[string]map[string]bool{
+	"cluster": {"info": true, "nodes": true, "slots": true, "shards": true, "links": true, "myid": true, "keyslot": true,
+		"countkeysinslot": true, "getkeysinslot": true, "count-failure-reports": true, "replicas": true, "slaves": true},
+	"config": {"get": true},
+	"client": {"list": true, "info": true, "getname": true, "id": true},
+	"acl":    {"list": true, "users": true, "whoami": true, "cat": true, "getuser": true, "log": true},
+}
+
+// callEntry is the entry pod of mutating calls, read only calls are not audited
+func callEntry(args []string) string {
+	if len(args) < 2 {
+		return ""
+	}
+	name := strings.ToLower(args[1])
+	if readonlyCommands[name] {
+		return ""
+	}
+	if subs, ok := readonlySubcommands[name]; ok && len(args) > 2 && subs[strings.ToLower(args[2])] {
+		return ""
+	}
+	return args[0]
+}
+
+// callTargets are the pod called, or every pod of cluster with --all
+func callTargets(cmd *cobra.Command, args []string, before map[string]string) []string {
+	if all, _ := cmd.Flags().GetBool("all"); !all {
+		return []string{args[0]}
+	}
+	pods := make([]string, 0, len(before))
+	for p := range before {
+		pods = append(pods, p)
+	}
+	return pods
+}
+
+// callRedact hides arguments of commands carrying credentials, eg: auth, config set requirepass
+func callRedact(args []string) []string {
+	res := append([]string{}, args...)
+	from := len(res)
+	switch strings.ToLower(res[1]) {
+	case "auth", "hello", "acl", "migrate":
+		from = 2
+	case "config":
+		if len(res) > 3 && strings.ToLower(res[2]) == "set" {
+			switch strings.ToLower(res[3]) {
+			case "requirepass", "masterauth", "masteruser":
+				from = 4
+			}
+		}
+	}
+	for i := from; i < len(res); i++ {
+		res[i] = "***"
+	}
+	return res
+}
+
 func init() {
 	callCmd.Flags().Bool("all", false, "run on all redis nodes")
+	auditWith(callCmd, callEntry, auditOptions{targets: callTargets, redact: callRedact})
+	callCmd.ValidArgsFunction = completeRedisPods(1)
 	rootCmd.AddCommand(callCmd)
 }
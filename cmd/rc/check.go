@@ -31,11 +31,11 @@ var checkCmd = &cobra.Command{
 	Use:   "check",
 	Short: "Check nodes for slots configuration",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p, err := redis.NewRedisPod(args[0], containerName, namespace, redisPort, clientset, restcfg)
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		if err := p.ClusterCheck(); err != nil {
+		if err := p.ClusterCheck(ctx); err != nil {
 			return err
 		}
 		return nil
@@ -43,5 +43,6 @@ var checkCmd = &cobra.Command{
 }
 
 func init() {
+	checkCmd.ValidArgsFunction = completeRedisPods(1)
 	rootCmd.AddCommand(checkCmd)
 }
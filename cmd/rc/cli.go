@@ -0,0 +1,220 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var cliReadonly bool
+
+const cliHelp = `Commands are routed by key slot to the owning master (reads to a slave with --readonly),
+commands without key go to current pod, MOVED/ASK redirects are followed.
+MULTI pins following commands to current pod until EXEC/DISCARD.
+
+  .nodes        list cluster nodes, * marks current pod
+  .use <pod>    send commands without key to pod
+  .refresh      reload slots from cluster
+  .history      show commands of this session
+  .help         show this help
+  .exit         quit, also: exit, quit, Ctrl-D`
+
+// lineReader reads lines from a terminal with history and line editing, or from piped stdin
+type lineReader interface {
+	ReadLine() (string, error)
+	SetPrompt(prompt string)
+}
+
+type pipeReader struct {
+	scanner *bufio.Scanner
+}
+
+func (r *pipeReader) ReadLine() (string, error) {
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+func (r *pipeReader) SetPrompt(prompt string) {}
+
+// cliCmd represents the cli command
+var cliCmd = &cobra.Command{
+	Use:   "cli <pod>",
+	Short: "Interactive redis-cli routing commands by key slot across the cluster",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pod, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		session, err := redis.NewClusterSession(ctx, pod, cliReadonly)
+		if err != nil {
+			return err
+		}
+		defer session.Close()
+
+		var reader lineReader
+		var out io.Writer = os.Stdout
+		fd := int(os.Stdin.Fd())
+		if terminal.IsTerminal(fd) {
+			state, err := terminal.MakeRaw(fd)
+			if err != nil {
+				return err
+			}
+			defer terminal.Restore(fd, state)
+			t := terminal.NewTerminal(struct {
+				io.Reader
+				io.Writer
+			}{os.Stdin, os.Stdout}, "")
+			if w, h, err := terminal.GetSize(fd); err == nil {
+				t.SetSize(w, h)
+			}
+			reader, out = t, t
+		} else {
+			reader = &pipeReader{scanner: bufio.NewScanner(os.Stdin)}
+		}
+
+		history := make([]string, 0)
+		for {
+			prompt := session.Current
+			if cliReadonly {
+				prompt += "(readonly)"
+			}
+			reader.SetPrompt(prompt + "> ")
+			line, err := reader.ReadLine()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			history = append(history, line)
+			args, err := redis.SplitArgs(line)
+			if err != nil {
+				fmt.Fprintln(out, "(error) "+err.Error())
+				continue
+			}
+			switch strings.ToLower(args[0]) {
+			case ".exit", "exit", "quit":
+				return nil
+			case ".help":
+				fmt.Fprintln(out, cliHelp)
+				continue
+			case ".history":
+				for i, h := range history {
+					fmt.Fprintf(out, "%4d  %s\n", i+1, h)
+				}
+				continue
+			case ".refresh":
+				if err := session.Refresh(ctx); err != nil {
+					fmt.Fprintln(out, "(error) "+err.Error())
+				}
+				continue
+			case ".use":
+				if len(args) != 2 {
+					fmt.Fprintln(out, "(error) usage: .use <pod>")
+				} else if err := session.Use(ctx, args[1]); err != nil {
+					fmt.Fprintln(out, "(error) "+err.Error())
+				}
+				continue
+			case ".nodes":
+				if err := printSessionNodes(out, pod, session.Current); err != nil {
+					fmt.Fprintln(out, "(error) "+err.Error())
+				}
+				continue
+			}
+			if strings.HasPrefix(args[0], ".") {
+				fmt.Fprintf(out, "(error) unknown command %s, try .help\n", args[0])
+				continue
+			}
+			reply, hops, err := session.Do(ctx, args...)
+			if err != nil {
+				fmt.Fprintln(out, "(error) "+err.Error())
+			} else {
+				fmt.Fprintln(out, redis.FormatReply(reply))
+			}
+			if len(hops) > 0 {
+				fmt.Fprintf(out, "(%s)\n", formatHops(hops))
+			}
+		}
+	},
+}
+
+// formatHops formats visited pods, eg: rc-0 -MOVED-> rc-1
+func formatHops(hops []string) string {
+	s := hops[0]
+	for i := 1; i+1 < len(hops); i += 2 {
+		s += fmt.Sprintf(" -%s-> %s", hops[i], hops[i+1])
+	}
+	return s
+}
+
+func printSessionNodes(out io.Writer, pod *redis.RedisPod, current string) error {
+	nodes, err := pod.ClusterNodes(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*redis.RedisNode)
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, n := range nodes {
+		mark := " "
+		if n.Pod.Name == current {
+			mark = "*"
+		}
+		if n.IsMaster() {
+			slots := make([]string, 0, len(n.Slots))
+			for _, s := range n.Slots {
+				slots = append(slots, s.String())
+			}
+			fmt.Fprintf(w, "%s %s\tmaster\t%s\n", mark, n.Pod.Name, strings.Join(slots, ","))
+		} else {
+			fmt.Fprintf(w, "%s %s\tslave of %s\t\n", mark, n.Pod.Name, nodeName(byID[n.MasterID]))
+		}
+	}
+	return w.Flush()
+}
+
+func init() {
+	cliCmd.Flags().BoolVar(&cliReadonly, "readonly", false, "route keyed reads to slaves, writes go to masters")
+	cliCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(cliCmd)
+}
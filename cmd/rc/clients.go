@@ -0,0 +1,236 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog/v2"
+)
+
+var (
+	clientsDetail   bool
+	clientsKill     bool
+	clientsYes      bool
+	clientsAddr     string
+	clientsUser     string
+	clientsIdleGt   int
+	clientsSelector string
+)
+
+type clientRecord struct {
+	*redis.ClientInfo
+	node   *redis.RedisNode
+	source string // client pod name, or ip if it's not a pod in namespace
+}
+
+type clientGroup struct {
+	source  string
+	count   int
+	nodes   map[string]bool
+	minIdle int
+	maxIdle int
+	qbuf    int
+	omem    int
+	cmds    map[string]int
+}
+
+// clientsCmd represents the clients command
+var clientsCmd = &cobra.Command{
+	Use:   "clients <pod>",
+	Short: "Show client connections of all redis nodes, grouped by source",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		nodes, err := p.ClusterNodes(ctx)
+		if err != nil {
+			return err
+		}
+		podList, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return err
+		}
+		pods := common.NewPodIndex(podList.Items)
+		records := make([]*clientRecord, 0)
+		for _, n := range nodes {
+			rp := redis.NewRedisPodWithPod(n.Pod, containerName, redisPort, conn, clientset, restcfg)
+			clients, err := rp.ClientList(ctx)
+			if err != nil {
+				return err
+			}
+			for _, c := range clients {
+				source := c.IP()
+				if pod, ok := pods.Find(source); ok {
+					source = pod.Name
+				}
+				records = append(records, &clientRecord{ClientInfo: c, node: n, source: source})
+			}
+		}
+		if clientsKill {
+			return killClients(records)
+		}
+		if clientsDetail {
+			printClients(records)
+			return nil
+		}
+
+		groups := make(map[string]*clientGroup)
+		for _, r := range records {
+			g, ok := groups[r.source]
+			if !ok {
+				g = &clientGroup{source: r.source, nodes: make(map[string]bool), minIdle: r.Idle, cmds: make(map[string]int)}
+				groups[r.source] = g
+			}
+			g.count++
+			g.nodes[r.node.Pod.Name] = true
+			if r.Idle < g.minIdle {
+				g.minIdle = r.Idle
+			}
+			if r.Idle > g.maxIdle {
+				g.maxIdle = r.Idle
+			}
+			g.qbuf += r.Qbuf
+			g.omem += r.Omem
+			g.cmds[r.Cmd]++
+		}
+		sorted := make([]*clientGroup, 0, len(groups))
+		for _, g := range groups {
+			sorted = append(sorted, g)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].count > sorted[j].count
+		})
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "source\tconns\tnodes\tmin idle\tmax idle\tqbuf\tomem\tcmds\t")
+		for _, g := range sorted {
+			cmds := make([]string, 0, len(g.cmds))
+			for c, n := range g.cmds {
+				cmds = append(cmds, fmt.Sprintf("%s:%d", c, n))
+			}
+			sort.Strings(cmds)
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%d\t%d\t%s\t\n", g.source, g.count, len(g.nodes), g.minIdle, g.maxIdle, g.qbuf, g.omem, truncate(strings.Join(cmds, ","), 60))
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func printClients(records []*clientRecord) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "node\tid\taddr\tsource\tuser\tname\tage\tidle\tflags\tcmd\tqbuf\tomem\t")
+	for _, r := range records {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\t%s\t%d\t%d\t\n", r.node.Pod.Name, r.ID, r.Addr, r.source, r.User, r.Name, r.Age, r.Idle, r.Flags, r.Cmd, r.Qbuf, r.Omem)
+	}
+	w.Flush()
+}
+
+// killClients kills connections matching all filters, replication links are never killed
+func killClients(records []*clientRecord) error {
+	if clientsAddr == "" && clientsUser == "" && clientsIdleGt <= 0 && clientsSelector == "" {
+		return errors.New("at least one of --addr, --acl-user, --idle-gt, --selector is required with --kill")
+	}
+	var selected common.PodIndex
+	if clientsSelector != "" {
+		podList, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: clientsSelector})
+		if err != nil {
+			return err
+		}
+		selected = common.NewPodIndex(podList.Items)
+	}
+	matched := make([]*clientRecord, 0)
+	for _, r := range records {
+		if r.IsReplication() {
+			continue
+		}
+		if clientsAddr != "" && r.Addr != clientsAddr && r.IP() != clientsAddr {
+			continue
+		}
+		if clientsUser != "" && r.User != clientsUser {
+			continue
+		}
+		if clientsIdleGt > 0 && r.Idle <= clientsIdleGt {
+			continue
+		}
+		if selected != nil {
+			if _, ok := selected.Find(r.IP()); !ok {
+				continue
+			}
+		}
+		matched = append(matched, r)
+	}
+	if len(matched) == 0 {
+		fmt.Println("no client matched")
+		return nil
+	}
+	printClients(matched)
+	if !clientsYes && !confirm(fmt.Sprintf("kill %d connections above?", len(matched))) {
+		return errors.New("aborted")
+	}
+	killed, skipped := 0, 0
+	for _, r := range matched {
+		rp := redis.NewRedisPodWithPod(r.node.Pod, containerName, redisPort, conn, clientset, restcfg)
+		ok, err := rp.ClientKill(ctx, r.ID)
+		if err != nil {
+			fmt.Printf("killed %d connections, %d already gone\n", killed, skipped)
+			return err
+		}
+		if !ok {
+			// short lived connections may close between client list and kill
+			klog.Warningf("client %s on %s is already gone", r.ID, r.node.Pod.Name)
+			skipped++
+			continue
+		}
+		killed++
+	}
+	fmt.Printf("killed %d connections, %d already gone\n", killed, skipped)
+	return nil
+}
+
+func init() {
+	clientsCmd.Flags().BoolVar(&clientsDetail, "detail", false, "list every connection instead of grouping by source")
+	clientsCmd.Flags().BoolVar(&clientsKill, "kill", false, "kill connections matching filters")
+	clientsCmd.Flags().BoolVar(&clientsYes, "yes", false, "don't ask for confirmation before killing")
+	clientsCmd.Flags().StringVar(&clientsAddr, "addr", "", "kill filter: client ip or ip:port")
+	clientsCmd.Flags().StringVar(&clientsUser, "acl-user", "", "kill filter: acl user of connection")
+	clientsCmd.Flags().IntVar(&clientsIdleGt, "idle-gt", 0, "kill filter: idle seconds greater than")
+	clientsCmd.Flags().StringVarP(&clientsSelector, "selector", "l", "", "kill filter: label selector of client pods")
+	audit(clientsCmd, func(args []string) string {
+		if clientsKill {
+			return args[0]
+		}
+		return ""
+	})
+	clientsCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(clientsCmd)
+}
@@ -40,13 +40,13 @@ var createCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		pods := make([]*redis.RedisPod, 0, len(args))
 		for _, name := range args {
-			p, err := redis.NewRedisPod(name, containerName, namespace, redisPort, clientset, restcfg)
+			p, err := redis.NewRedisPod(ctx, name, containerName, namespace, redisPort, conn, clientset, restcfg)
 			if err != nil {
 				return err
 			}
 			pods = append(pods, p)
 		}
-		if res, err := pods[0].ClusterCreate(createReplicas, createYes, pods[1:]...); err != nil {
+		if res, err := pods[0].ClusterCreate(ctx, createReplicas, createYes, pods[1:]...); err != nil {
 			return err
 		} else {
 			fmt.Println(res)
@@ -58,5 +58,7 @@ var createCmd = &cobra.Command{
 func init() {
 	createCmd.Flags().IntVar(&createReplicas, "replicas", 0, "replicas in cluster")
 	createCmd.Flags().BoolVar(&createYes, "yes", false, "don't ask")
+	audit(createCmd, firstArg)
+	createCmd.ValidArgsFunction = completeRedisPods(0)
 	rootCmd.AddCommand(createCmd)
 }
@@ -28,6 +28,8 @@ import (
 )
 
 var entryPodName string
+var delNodeYes bool
+var delNodeForce bool
 
 // delNodeCmd represents the delNode command
 var delNodeCmd = &cobra.Command{
@@ -35,22 +37,29 @@ var delNodeCmd = &cobra.Command{
 	Short: "Delete a node from redis cluster",
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error{
-		podToDelete, err := redis.NewRedisPod(args[0], containerName, namespace, redisPort, clientset, restcfg)
+		podToDelete, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		nodeID, err := podToDelete.GetNodeID()
+		nodeID, err := podToDelete.GetNodeID(ctx)
 		if err != nil {
 			return err
 		}
 		entryPod := podToDelete
 		if entryPodName != "" {
-			entryPod, err = redis.NewRedisPod(entryPodName, containerName, namespace, redisPort, clientset, restcfg)
+			entryPod, err = redis.NewRedisPod(ctx, entryPodName, containerName, namespace, redisPort, conn, clientset, restcfg)
 			if err != nil {
 				return err
 			}
 		}
-		if res, err := entryPod.ClusterDelNode(nodeID); err != nil {
+		issues, err := entryPod.DelNodePreflight(ctx, nodeID)
+		if err != nil {
+			return err
+		}
+		if err := preflight(issues, true, delNodeYes, delNodeForce, "--force"); err != nil {
+			return err
+		}
+		if res, err := entryPod.ClusterDelNode(ctx, nodeID); err != nil {
 			return err
 		} else {
 			fmt.Println(res)
@@ -61,5 +70,15 @@ var delNodeCmd = &cobra.Command{
 
 func init() {
 	delNodeCmd.Flags().StringVar(&entryPodName, "entry-pod", "", "send del-node cmd to entry-pod, not target del pod")
+	delNodeCmd.Flags().BoolVar(&delNodeYes, "yes", false, "don't ask for confirmation")
+	delNodeCmd.Flags().BoolVar(&delNodeForce, "force", false, "delete even if preflight checks refuse, eg: node still owns slots")
+	audit(delNodeCmd, func(args []string) string {
+		if entryPodName != "" {
+			return entryPodName
+		}
+		return args[0]
+	})
+	delNodeCmd.ValidArgsFunction = completeRedisPods(1)
+	delNodeCmd.RegisterFlagCompletionFunc("entry-pod", completeRedisPodFlag)
 	rootCmd.AddCommand(delNodeCmd)
 }
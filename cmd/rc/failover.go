@@ -30,6 +30,9 @@ import (
 var (
 	failoverForce bool
 	failoverTakeforce bool
+	failoverYes bool
+	failoverIgnorePreflight bool
+	failoverMaxLag int64
 )
 // failoverCmd represents the failover command
 var failoverCmd = &cobra.Command{
@@ -37,11 +40,18 @@ var failoverCmd = &cobra.Command{
 	Short: "Promote a slave to master",
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		pod, err := redis.NewRedisPod(args[0], containerName, namespace, redisPort, clientset, restcfg)
+		pod, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		if res, err := pod.ClusterFailover(failoverForce, failoverTakeforce); err != nil {
+		issues, err := pod.FailoverPreflight(ctx, failoverForce, failoverTakeforce, failoverMaxLag)
+		if err != nil {
+			return err
+		}
+		if err := preflight(issues, failoverForce || failoverTakeforce, failoverYes, failoverIgnorePreflight, "--ignore-preflight"); err != nil {
+			return err
+		}
+		if res, err := pod.ClusterFailover(ctx, failoverForce, failoverTakeforce); err != nil {
 			return err
 		} else {
 			fmt.Println(res)
@@ -53,5 +63,10 @@ var failoverCmd = &cobra.Command{
 func init() {
 	failoverCmd.Flags().BoolVar(&failoverForce,"force", false, "do manual failover without handshake with master")
 	failoverCmd.Flags().BoolVar(&failoverTakeforce,"takeover", false, "do manual failover without cluster consensus")
+	failoverCmd.Flags().BoolVar(&failoverYes, "yes", false, "don't ask for confirmation")
+	failoverCmd.Flags().BoolVar(&failoverIgnorePreflight, "ignore-preflight", false, "failover even if preflight checks refuse, eg: takeover while master is reachable")
+	failoverCmd.Flags().Int64Var(&failoverMaxLag, "max-lag", 1024*1024, "max replication offset lag in bytes of slave to promote")
+	audit(failoverCmd, firstArg)
+	failoverCmd.ValidArgsFunction = completeSlaves
 	rootCmd.AddCommand(failoverCmd)
 }
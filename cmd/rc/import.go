@@ -0,0 +1,147 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importFromPod  string
+	importFromPort int
+	importOpts     redis.ImportOptions
+	importYes      bool
+)
+
+// importCmd represents the import command
+var importCmd = &cobra.Command{
+	Use:   "import <cluster-pod> --from-pod <standalone-pod>",
+	Short: "Import keys from a standalone redis into redis cluster",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if importFromPod == "" {
+			return errors.New("--from-pod is required")
+		}
+		fromPort := importFromPort
+		if fromPort == 0 {
+			fromPort = redisPort
+		}
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		source, err := redis.NewRedisPod(ctx, importFromPod, containerName, namespace, fromPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		sourceBefore, err := source.DBSize(ctx)
+		if err != nil {
+			return err
+		}
+		clusterBefore, err := clusterDBSize(p)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d keys in %s, %d keys in cluster\n", sourceBefore, source.GetName(), clusterBefore)
+		if !importOpts.Copy && !importYes && !confirm(fmt.Sprintf("imported keys will be deleted from %s, continue?", source.GetName())) {
+			return errors.New("aborted")
+		}
+
+		result, err := p.Import(ctx, source, &importOpts, func(r *redis.ImportResult) {
+			printProgress(r.Scanned, sourceBefore)
+		})
+		fmt.Println()
+		if result != nil {
+			fmt.Printf("scanned: %d, imported: %d, failed: %d\n", result.Scanned, result.Imported, result.Failed)
+		}
+		if err != nil {
+			return err
+		}
+		sourceAfter, err := source.DBSize(ctx)
+		if err != nil {
+			return err
+		}
+		clusterAfter, err := clusterDBSize(p)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s keys: %d -> %d\n", source.GetName(), sourceBefore, sourceAfter)
+		fmt.Printf("cluster keys: %d -> %d\n", clusterBefore, clusterAfter)
+		return nil
+	},
+}
+
+// clusterDBSize returns sum of dbsize of all masters
+func clusterDBSize(p *redis.RedisPod) (int64, error) {
+	nodes, err := p.ClusterNodes(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, n := range nodes {
+		if !n.IsMaster() {
+			continue
+		}
+		size, err := redis.NewRedisPodWithPod(n.Pod, containerName, redisPort, conn, clientset, restcfg).DBSize(ctx)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// printProgress prints a progress bar in place
+func printProgress(done, total int64) {
+	const width = 40
+	if total <= 0 {
+		fmt.Printf("\r%d", done)
+		return
+	}
+	ratio := float64(done) / float64(total)
+	if ratio > 1 {
+		ratio = 1
+	}
+	filled := int(ratio * width)
+	fmt.Printf("\r[%s%s] %3.0f%% %d/%d", strings.Repeat("=", filled), strings.Repeat(" ", width-filled), ratio*100, done, total)
+}
+
+func init() {
+	importCmd.Flags().StringVar(&importFromPod, "from-pod", "", "standalone redis pod to import keys from")
+	importCmd.Flags().IntVar(&importFromPort, "from-port", 0, "redis port of --from-pod, default to --port")
+	importCmd.Flags().StringVar(&importOpts.Method, "method", "migrate", "migrate: source pod migrates keys to masters, restore: dump/restore through port-forward")
+	importCmd.Flags().StringVar(&importOpts.Match, "match", "*", "only import keys matching pattern")
+	importCmd.Flags().IntVar(&importOpts.Batch, "batch", 100, "keys per scan/migrate batch")
+	importCmd.Flags().IntVar(&importOpts.Timeout, "timeout", 5000, "migrate timeout in milliseconds")
+	importCmd.Flags().BoolVar(&importOpts.Copy, "copy", true, "keep keys in source, --copy=false deletes them after import")
+	importCmd.Flags().BoolVar(&importOpts.Replace, "replace", false, "replace existing keys in cluster")
+	importCmd.Flags().BoolVar(&importYes, "yes", false, "skip confirmation of deleting keys from source with --copy=false")
+	audit(importCmd, firstArg)
+	importCmd.ValidArgsFunction = completeRedisPods(1)
+	importCmd.RegisterFlagCompletionFunc("from-pod", completeRedisPodFlag)
+	rootCmd.AddCommand(importCmd)
+}
@@ -34,11 +34,11 @@ var infoCmd = &cobra.Command{
 	Short: "Get redis cluster info",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p, err := redis.NewRedisPod(args[0], containerName, namespace, redisPort, clientset, restcfg)
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		if res, err := p.ClusterInfo(); err != nil {
+		if res, err := p.ClusterInfo(ctx); err != nil {
 			return err
 		} else {
 			fmt.Println(res)
@@ -48,5 +48,6 @@ var infoCmd = &cobra.Command{
 }
 
 func init() {
+	infoCmd.ValidArgsFunction = completeRedisPods(1)
 	rootCmd.AddCommand(infoCmd)
 }
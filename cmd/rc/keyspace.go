@@ -0,0 +1,161 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+)
+
+var (
+	keyspaceSample int
+	keyspaceTop    int
+)
+
+// keyspaceCmd represents the keyspace command
+var keyspaceCmd = &cobra.Command{
+	Use:   "keyspace <pod>",
+	Short: "Show keyspace statistics of masters, skew between them, heaviest slots and replica dbsize",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		nodes, err := p.ClusterNodes(ctx)
+		if err != nil {
+			return err
+		}
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].Pod.Name < nodes[j].Pod.Name })
+		stats := p.LoadKeyspace(ctx, nodes)
+		masters := make([]*redis.Keyspace, 0)
+		slaves := make([]*redis.Keyspace, 0)
+		byID := make(map[string]*redis.Keyspace)
+		var totalKeys, totalExpires int64
+		totalSlots := 0
+		for _, ks := range stats {
+			byID[ks.Node.ID] = ks
+			if !ks.Node.IsMaster() {
+				slaves = append(slaves, ks)
+				continue
+			}
+			masters = append(masters, ks)
+			totalKeys += ks.Keys
+			totalExpires += ks.Expires
+			totalSlots += ks.Node.SlotsCount()
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MASTER\tSLOTS\tKEYS\tEXPIRES\tAVG TTL\tSLOTS%\tKEYS%\tKEYS/SLOT")
+		keys := make([]float64, 0)
+		perSlot := make([]float64, 0)
+		for _, ks := range masters {
+			if ks.Err != nil {
+				fmt.Fprintf(w, "%s\t%s\n", ks.Node.Pod.Name, ks.Err)
+				continue
+			}
+			slots := ks.Node.SlotsCount()
+			keys = append(keys, float64(ks.Keys))
+			density := "-"
+			if slots > 0 {
+				perSlot = append(perSlot, float64(ks.Keys)/float64(slots))
+				density = fmt.Sprintf("%.1f", float64(ks.Keys)/float64(slots))
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%s\t%s\t%s\n", ks.Node.Pod.Name, slots, ks.Keys, ks.Expires,
+				time.Duration(ks.AvgTTL)*time.Millisecond, percent(int64(slots), int64(totalSlots)), percent(ks.Keys, totalKeys), density)
+		}
+		fmt.Fprintf(w, "TOTAL\t%d\t%d\t%d\t\t\t\t\n", totalSlots, totalKeys, totalExpires)
+		w.Flush()
+		fmt.Printf("\nSkew (max/avg, 1.00 is even): keys %.2f, keys per slot %.2f\n", redis.Skew(keys), redis.Skew(perSlot))
+
+		if keyspaceTop > 0 {
+			heaviest := make([]redis.SlotKeys, 0)
+			for _, ks := range masters {
+				if ks.Node.SlotsCount() == 0 {
+					continue
+				}
+				res, err := p.SampleSlotKeys(ctx, ks.Node, keyspaceSample)
+				if err != nil {
+					fmt.Printf("failed to count keys in slots of %s: %v\n", ks.Node.Pod.Name, err)
+					continue
+				}
+				heaviest = append(heaviest, res...)
+			}
+			sort.Slice(heaviest, func(i, j int) bool { return heaviest[i].Keys > heaviest[j].Keys })
+			if len(heaviest) > keyspaceTop {
+				heaviest = heaviest[:keyspaceTop]
+			}
+			sampled := "all slots"
+			if keyspaceSample > 0 {
+				sampled = fmt.Sprintf("%d sampled slots per master", keyspaceSample)
+			}
+			fmt.Printf("\nHeaviest slots (%s):\n", sampled)
+			w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SLOT\tMASTER\tKEYS")
+			for _, s := range heaviest {
+				fmt.Fprintf(w, "%d\t%s\t%d\n", s.Slot, s.Master, s.Keys)
+			}
+			w.Flush()
+		}
+
+		if len(slaves) > 0 {
+			fmt.Println("\nReplicas:")
+			w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SLAVE\tMASTER\tDBSIZE\tMASTER KEYS\tDIFF")
+			for _, ks := range slaves {
+				m, ok := byID[ks.Node.MasterID]
+				if !ok {
+					fmt.Fprintf(w, "%s\t%s\t%d\t-\t-\n", ks.Node.Pod.Name, "?", ks.Keys)
+					continue
+				}
+				if ks.Err != nil || m.Err != nil {
+					fmt.Fprintf(w, "%s\t%s\t-\t-\t-\n", ks.Node.Pod.Name, m.Node.Pod.Name)
+					continue
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%+d\n", ks.Node.Pod.Name, m.Node.Pod.Name, ks.Keys, m.Keys, ks.Keys-m.Keys)
+			}
+			w.Flush()
+		}
+		return nil
+	},
+}
+
+// percent formats n/total as percentage
+func percent(n, total int64) string {
+	if total == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+func init() {
+	keyspaceCmd.Flags().IntVar(&keyspaceSample, "sample", 256, "slots sampled per master to find heaviest slots, 0 counts all slots")
+	keyspaceCmd.Flags().IntVar(&keyspaceTop, "top", 10, "show this many heaviest slots, 0 to skip slot sampling")
+	keyspaceCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(keyspaceCmd)
+}
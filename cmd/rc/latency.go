@@ -0,0 +1,144 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var (
+	latencyThreshold int
+	latencyDuration  time.Duration
+	latencyDoctor    bool
+)
+
+// latencyCmd represents the latency command
+var latencyCmd = &cobra.Command{
+	Use:   "latency <pod>",
+	Short: "Collect latency monitor events from all redis nodes",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		nodes, err := p.ClusterNodes(ctx)
+		if err != nil {
+			return err
+		}
+		sort.Slice(nodes, func(i, j int) bool {
+			return nodes[i].Pod.Name < nodes[j].Pod.Name
+		})
+		pods := make([]*redis.RedisPod, 0, len(nodes))
+		for _, n := range nodes {
+			pods = append(pods, redis.NewRedisPodWithPod(n.Pod, containerName, redisPort, conn, clientset, restcfg))
+		}
+
+		// enable latency monitor on nodes without it, restore when finished, even if ctx is cancelled
+		enabled := make([]*redis.RedisPod, 0)
+		defer func() {
+			restoreCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			for _, pod := range enabled {
+				if _, err := pod.ConfigSet(restoreCtx, "latency-monitor-threshold", "0"); err != nil {
+					klog.Errorf("failed to restore latency-monitor-threshold on %s: %v", pod.GetName(), err)
+				}
+			}
+		}()
+		for _, pod := range pods {
+			val, err := pod.ConfigGetValue(ctx, "latency-monitor-threshold")
+			if err != nil {
+				return err
+			}
+			if val != "0" {
+				continue
+			}
+			if _, err := pod.ConfigSet(ctx, "latency-monitor-threshold", strconv.Itoa(latencyThreshold)); err != nil {
+				return err
+			}
+			enabled = append(enabled, pod)
+		}
+		if len(enabled) > 0 {
+			fmt.Printf("latency monitor enabled on %d nodes with threshold %dms, collecting for %s\n", len(enabled), latencyThreshold, latencyDuration)
+			select {
+			case <-time.After(latencyDuration):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "pod\tevent\tlast time\tlatest\tmax\tsamples\tavg\t")
+		for _, pod := range pods {
+			events, err := pod.LatencyLatest(ctx)
+			if err != nil {
+				return err
+			}
+			for _, e := range events {
+				samples, err := pod.LatencyHistory(ctx, e.Name)
+				if err != nil {
+					return err
+				}
+				var avg time.Duration
+				if len(samples) > 0 {
+					var total time.Duration
+					for _, s := range samples {
+						total += s.Latency
+					}
+					avg = total / time.Duration(len(samples))
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%d\t%s\t\n", pod.GetName(), e.Name, e.Time.Format("2006-01-02 15:04:05"), e.Latest, e.Max, len(samples), avg)
+			}
+		}
+		w.Flush()
+
+		if latencyDoctor {
+			for _, pod := range pods {
+				res, err := pod.LatencyDoctor(ctx)
+				if err != nil {
+					return err
+				}
+				fmt.Println(">>> " + pod.GetName() + ":")
+				fmt.Println(res)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	latencyCmd.Flags().IntVar(&latencyThreshold, "threshold", 100, "latency-monitor-threshold in milliseconds, set on nodes without latency monitor")
+	latencyCmd.Flags().DurationVar(&latencyDuration, "duration", 30*time.Second, "how long to collect events after enabling latency monitor")
+	latencyCmd.Flags().BoolVar(&latencyDoctor, "doctor", false, "show latency doctor report of every node")
+	latencyCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(latencyCmd)
+}
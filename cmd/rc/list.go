@@ -0,0 +1,79 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var listAllNamespaces bool
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Discover redis clusters in namespace by probing redis pods",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ns := namespace
+		if listAllNamespaces {
+			ns = metav1.NamespaceAll
+		}
+		pods, err := common.ListRedisPods(ctx, clientset, ns, redisPort)
+		if err != nil {
+			return err
+		}
+		clusters := redis.FindClusters(ctx, pods, containerName, redisPort, conn, clientset, restcfg)
+		if len(clusters) == 0 {
+			fmt.Println("no redis cluster found")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		if listAllNamespaces {
+			fmt.Fprint(w, "NAMESPACE\t")
+		}
+		fmt.Fprintln(w, "STATEFULSET\tPODS\tNODES\tMASTERS\tREPLICAS\tSTATE\tVERSION\tENTRY")
+		for _, c := range clusters {
+			if listAllNamespaces {
+				fmt.Fprintf(w, "%s\t", c.Namespace)
+			}
+			sts := strings.Join(c.StatefulSets, ",")
+			if sts == "" {
+				sts = "-"
+			}
+			fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%s\t%s\t%s\n", sts, len(c.Pods), c.Nodes, c.Masters, c.Replicas, c.State, c.Version, c.Pods[0])
+		}
+		return w.Flush()
+	},
+}
+
+func init() {
+	listCmd.Flags().BoolVarP(&listAllNamespaces, "all-namespaces", "A", false, "discover clusters in all namespaces")
+	rootCmd.AddCommand(listCmd)
+}
@@ -0,0 +1,163 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+)
+
+var (
+	monitorSample   float64
+	monitorDuration time.Duration
+	monitorMatch    string
+	monitorTop      int
+)
+
+// monitorCmd represents the monitor command
+var monitorCmd = &cobra.Command{
+	Use:   "monitor <pod>",
+	Short: "Run monitor on all masters for a limited duration",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if monitorSample <= 0 || monitorSample > 1 {
+			return errors.New("sample should be in (0, 1]")
+		}
+		if monitorDuration <= 0 {
+			return errors.New("duration must > 0")
+		}
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		nodes, err := p.ClusterNodes(ctx)
+		if err != nil {
+			return err
+		}
+
+		monitorCtx, cancel := context.WithTimeout(ctx, monitorDuration)
+		defer cancel()
+		var mu sync.Mutex
+		total := 0
+		cmds := make(map[string]int)
+		prefixes := make(map[string]int)
+		var wg sync.WaitGroup
+		errCh := make(chan error, len(nodes))
+		for _, n := range nodes {
+			if !n.IsMaster() {
+				continue
+			}
+			pod := redis.NewRedisPodWithPod(n.Pod, containerName, redisPort, conn, clientset, restcfg)
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := pod.Monitor(monitorCtx, func(line *redis.MonitorLine) {
+					key := ""
+					if len(line.Args) > 1 {
+						key = line.Args[1]
+					}
+					if monitorMatch != "" {
+						if ok, _ := path.Match(monitorMatch, key); !ok {
+							return
+						}
+					}
+					mu.Lock()
+					defer mu.Unlock()
+					total++
+					if len(line.Args) > 0 {
+						cmds[strings.ToLower(line.Args[0])]++
+					}
+					if key != "" {
+						prefixes[keyPrefix(key)]++
+					}
+					if monitorSample >= 1 || rand.Float64() < monitorSample {
+						fmt.Printf("%s: %s\n", pod.GetName(), line.Raw)
+					}
+				})
+				if err != nil {
+					errCh <- fmt.Errorf("%s: %v", pod.GetName(), err)
+					// stop all monitors if one fails
+					cancel()
+				}
+			}()
+		}
+		wg.Wait()
+		close(errCh)
+		if err, ok := <-errCh; ok {
+			return err
+		}
+
+		fmt.Printf("\n%d commands in %s\n", total, monitorDuration)
+		printTopCounts("command", cmds, monitorTop)
+		printTopCounts("key prefix", prefixes, monitorTop)
+		return nil
+	},
+}
+
+// keyPrefix returns key prefix before the first ':', eg: user:1:name -> user:*
+func keyPrefix(key string) string {
+	if i := strings.Index(key, ":"); i >= 0 {
+		return key[:i+1] + "*"
+	}
+	return key
+}
+
+func printTopCounts(title string, counts map[string]int, top int) {
+	keys := make([]string, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return counts[keys[i]] > counts[keys[j]]
+	})
+	if top > 0 && len(keys) > top {
+		keys = keys[:top]
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "%s\tcount\t\n", title)
+	for _, k := range keys {
+		fmt.Fprintf(w, "%s\t%d\t\n", k, counts[k])
+	}
+	w.Flush()
+}
+
+func init() {
+	monitorCmd.Flags().Float64Var(&monitorSample, "sample", 1, "ratio of commands to print, eg: 0.01, summary still counts all commands")
+	monitorCmd.Flags().DurationVar(&monitorDuration, "duration", 30*time.Second, "stop monitor after duration")
+	monitorCmd.Flags().StringVar(&monitorMatch, "match", "", "only include commands whose key matches glob pattern, eg: user:*")
+	monitorCmd.Flags().IntVar(&monitorTop, "top", 20, "number of top commands and key prefixes in summary")
+	monitorCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(monitorCmd)
+}
@@ -28,20 +28,23 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var nodesMaxLag int64
+
 // nodesCmd represents the nodes command
 var nodesCmd = &cobra.Command{
 	Use:   "nodes <pod>",
 	Short: "List nodes in redis cluster",
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p, err := redis.NewRedisPod(args[0], containerName, namespace, redisPort, clientset, restcfg)
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		nodes, err := p.ClusterNodes()
+		nodes, err := p.ClusterNodes(ctx)
 		if err != nil {
 			return err
 		}
+		p.LoadReplication(ctx, nodes)
 		masterMap := make(map[string]*redis.RedisNode)
 		slaveGroups := make(map[*redis.RedisNode][]*redis.RedisNode)
 		masterNodes := make([]*redis.RedisNode, 0)
@@ -69,18 +72,54 @@ var nodesCmd = &cobra.Command{
 				}
 			}
 		}
+		lagging := 0
 		for _, m := range masterNodes {
-			fmt.Println("Master:", m)
+			fmt.Printf("Master: %s, %s\n", m, replicationDesc(m))
 			if len(slaveGroups[m]) > 0 {
 				for _, s := range slaveGroups[m] {
-					fmt.Println("\t Slave:", s)
+					desc := replicationDesc(s)
+					if s.Replication != nil && s.Replication.Lagging(nodesMaxLag) {
+						desc += "  <-- LAGGING"
+						lagging++
+					}
+					fmt.Printf("\t Slave: %s, %s\n", s, desc)
 				}
 			}
 		}
+		if lagging > 0 {
+			fmt.Printf("%d slaves are lagging (lag > %d bytes, link down or syncing), check before failover\n", lagging, nodesMaxLag)
+		}
+		if openSlots := redis.OpenSlots(nodes); len(openSlots) > 0 {
+			fmt.Println("Open slots:")
+			for _, s := range openSlots {
+				fmt.Printf("\t slot %d: %s -> %s\n", s.Slot, nodeName(s.Source), nodeName(s.Target))
+			}
+		}
 		return nil
 	},
 }
 
+// replicationDesc describes replication state of node
+func replicationDesc(n *redis.RedisNode) string {
+	if n.Replication == nil {
+		return "replication: unknown"
+	}
+	return n.Replication.String()
+}
+
+// nodeName returns pod name of node, "?" if node is unknown
+func nodeName(n *redis.RedisNode) string {
+	if n == nil {
+		return "?"
+	}
+	if n.Pod == nil {
+		return n.ID
+	}
+	return n.Pod.Name
+}
+
 func init() {
+	nodesCmd.Flags().Int64Var(&nodesMaxLag, "max-lag", 1024*1024, "slaves behind master more than this bytes are highlighted")
+	nodesCmd.ValidArgsFunction = completeRedisPods(1)
 	rootCmd.AddCommand(nodesCmd)
 }
@@ -0,0 +1,111 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+	"github.com/spf13/cobra"
+)
+
+var persistenceDiskThreshold int
+
+// persistenceCmd represents the persistence command
+var persistenceCmd = &cobra.Command{
+	Use:   "persistence <pod>",
+	Short: "Report rdb/aof settings, state and data disk usage of all redis nodes",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pods, err := getClusterPods(args[0], true)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "POD\tSAVE\tLAST SAVE\tBGSAVE\tCHANGES\tAOF\tFSYNC\tAOF REWRITE\tAOF WRITE\tDISK\tPVC")
+		problems := make(map[string][]string)
+		for _, p := range pods {
+			s, err := p.Persistence(ctx)
+			if err != nil {
+				fmt.Fprintf(w, "%s\t%s\n", p.GetName(), err)
+				problems[p.GetName()] = []string{err.Error()}
+				continue
+			}
+			save := s.Save
+			if save == "" {
+				save = "off"
+			}
+			bgsave := s.Info["rdb_last_bgsave_status"]
+			if s.Info["rdb_bgsave_in_progress"] == "1" {
+				bgsave += ",running"
+			}
+			aof, rewrite, write := "off", "-", "-"
+			if s.Info["aof_enabled"] == "1" {
+				aof = "on"
+				rewrite = s.Info["aof_last_bgrewrite_status"]
+				if s.Info["aof_rewrite_in_progress"] == "1" {
+					rewrite += ",running"
+				}
+				write = s.Info["aof_last_write_status"]
+			}
+			disk := "-"
+			if s.Disk != nil {
+				disk = fmt.Sprintf("%s/%s (%d%%)", common.FormatBytes(s.Disk.Used), common.FormatBytes(s.Disk.Total), s.Disk.UsedPercent())
+			}
+			pvc := "-"
+			if s.PVC != "" && s.PVCCapacity > 0 {
+				pvc = fmt.Sprintf("%s (%s)", s.PVC, common.FormatBytes(s.PVCCapacity))
+			} else if s.PVC != "" {
+				pvc = s.PVC + " (?)"
+			}
+			lastSave := "-"
+			if t := s.LastSave(); !t.IsZero() {
+				lastSave = time.Since(t).Round(time.Second).String() + " ago"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", p.GetName(), save, lastSave, bgsave,
+				s.Info["rdb_changes_since_last_save"], aof, s.AppendFsync, rewrite, write, disk, pvc)
+			if ps := s.Problems(persistenceDiskThreshold); len(ps) > 0 {
+				problems[p.GetName()] = ps
+			}
+		}
+		w.Flush()
+		if len(problems) > 0 {
+			fmt.Println("\nProblems:")
+			for _, p := range pods {
+				if ps, ok := problems[p.GetName()]; ok {
+					fmt.Printf("\t%s: %s\n", p.GetName(), strings.Join(ps, "; "))
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	persistenceCmd.Flags().IntVar(&persistenceDiskThreshold, "disk-threshold", 85, "report data disk used over this percentage")
+	persistenceCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(persistenceCmd)
+}
@@ -0,0 +1,159 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var (
+	specFile         string
+	applyYes         bool
+	applyPipeline    int
+	applyTimeout     int
+	applyWaitTimeout time.Duration
+)
+
+// loadSpec reads and validates spec file
+func loadSpec() (*redis.ClusterSpec, error) {
+	if specFile == "" {
+		return nil, errors.New("-f is required")
+	}
+	data, err := ioutil.ReadFile(specFile)
+	if err != nil {
+		return nil, err
+	}
+	spec := new(redis.ClusterSpec)
+	if err := yaml.UnmarshalStrict(data, spec); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", specFile, err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return spec, nil
+}
+
+// loadPlan plans spec against the cluster of entry pod, entry defaults to first master in spec
+func loadPlan(args []string) (*redis.RedisPod, []*redis.PlanAction, error) {
+	spec, err := loadSpec()
+	if err != nil {
+		return nil, nil, err
+	}
+	entry := spec.Masters[0].Pod
+	if len(args) > 0 {
+		entry = args[0]
+	}
+	pod, err := redis.NewRedisPod(ctx, entry, containerName, namespace, redisPort, conn, clientset, restcfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	actions, err := pod.Plan(ctx, spec)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pod, actions, nil
+}
+
+func printPlan(actions []*redis.PlanAction) {
+	if len(actions) == 0 {
+		fmt.Println("cluster matches spec, nothing to do")
+		return
+	}
+	for i, a := range actions {
+		fmt.Printf("%d. %s (%s)\n", i+1, a, a.Reason)
+	}
+}
+
+// planCmd represents the plan command
+var planCmd = &cobra.Command{
+	Use:   "plan [pod] -f <spec.yaml>",
+	Short: "Show actions needed to make redis cluster match spec file",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		_, actions, err := loadPlan(args)
+		if err != nil {
+			return err
+		}
+		printPlan(actions)
+		return nil
+	},
+}
+
+// applyCmd represents the apply command
+var applyCmd = &cobra.Command{
+	Use:   "apply [pod] -f <spec.yaml>",
+	Short: "Execute actions needed to make redis cluster match spec file",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pod, actions, err := loadPlan(args)
+		if err != nil {
+			return err
+		}
+		printPlan(actions)
+		if len(actions) == 0 {
+			return nil
+		}
+		if !applyYes && !confirm("Apply above actions?") {
+			return errors.New("aborted")
+		}
+		for i, a := range actions {
+			fmt.Printf("[%d/%d] %s\n", i+1, len(actions), a)
+			res, err := pod.Apply(ctx, a, applyPipeline, applyTimeout, applyWaitTimeout)
+			if res != "" {
+				fmt.Println(res)
+			}
+			if err != nil {
+				return fmt.Errorf("%s failed: %v", a, err)
+			}
+		}
+		return nil
+	},
+}
+
+func init() {
+	planCmd.Flags().StringVarP(&specFile, "filename", "f", "", "cluster spec yaml file")
+	applyCmd.Flags().StringVarP(&specFile, "filename", "f", "", "cluster spec yaml file")
+	applyCmd.Flags().BoolVar(&applyYes, "yes", false, "apply without confirmation")
+	applyCmd.Flags().IntVar(&applyPipeline, "pipeline", 10, "migrate keys batch size during reshard")
+	applyCmd.Flags().IntVar(&applyTimeout, "timeout", 60000, "migrate timeout in milliseconds in single batch during reshard")
+	applyCmd.Flags().DurationVar(&applyWaitTimeout, "wait-timeout", 5*time.Minute, "max time to wait for cluster consistent after every action")
+	audit(applyCmd, func(args []string) string {
+		if len(args) > 0 {
+			return args[0]
+		}
+		if spec, err := loadSpec(); err == nil {
+			return spec.Masters[0].Pod
+		}
+		return ""
+	})
+	planCmd.ValidArgsFunction = completeRedisPods(1)
+	applyCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(planCmd)
+	rootCmd.AddCommand(applyCmd)
+}
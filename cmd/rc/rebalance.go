@@ -24,9 +24,17 @@ package main
 import (
 	"errors"
 	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
 	"github.com/monsterxx03/kuberc/pkg/redis"
 	"github.com/spf13/cobra"
-	"strings"
+	"golang.org/x/crypto/ssh/terminal"
 )
 
 var (
@@ -37,6 +45,11 @@ var (
 	rebalancePipeline       int
 	rebalanceThreshold      int
 	rebalanceReplace        bool
+	rebalanceBy             string
+	rebalanceMemorySample   int
+	rebalanceMaxKeysPerSec  int
+	rebalanceCheckpoint     string
+	rebalanceResume         bool
 )
 
 // rebalanceCmd represents the rebalance command
@@ -60,11 +73,14 @@ var rebalanceCmd = &cobra.Command{
 			}
 		}
 
-		pod, err := redis.NewRedisPod(args[0], containerName, namespace, redisPort, clientset, restcfg)
+		pod, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		if res, err := pod.ClusterRebalance(weights, rebalanceUseEmptyMaster, rebalanceTimeout, rebalanceSimulate, rebalancePipeline, rebalanceThreshold, rebalanceReplace); err != nil {
+		if rebalanceBy != "" || rebalanceResume {
+			return rebalanceNative(pod, weights)
+		}
+		if res, err := pod.ClusterRebalance(ctx, weights, rebalanceUseEmptyMaster, rebalanceTimeout, rebalanceSimulate, rebalancePipeline, rebalanceThreshold, rebalanceReplace); err != nil {
 			return err
 		} else {
 			fmt.Println(res)
@@ -73,6 +89,155 @@ var rebalanceCmd = &cobra.Command{
 	},
 }
 
+// rebalanceNative evens out slots, keys or memory of masters, migrating slots without redis-cli.
+// Finished slots are saved into a checkpoint file, an interrupted run is continued with --resume.
+func rebalanceNative(pod *redis.RedisPod, weights map[string]string) error {
+	if rebalanceTimeout <= 2000 {
+		return errors.New("timeout must > 2000 ms for safety.")
+	}
+	if rebalancePipeline <= 0 {
+		return errors.New("pipeline size must > 0")
+	}
+	path := rebalanceCheckpoint
+	if path == "" {
+		path = redis.DefaultCheckpointPath(common.KubeContext(kubeFlags), namespace, pod.ClusterName())
+	}
+	var cp *redis.MigrationCheckpoint
+	var err error
+	if path != "" && rebalanceResume {
+		// partial line is only cut from file when it will be appended to
+		if cp, err = redis.LoadCheckpoint(path, !rebalanceSimulate); err != nil {
+			return err
+		}
+		if cp == nil {
+			return fmt.Errorf("no checkpoint to resume at %s", path)
+		}
+	} else if path != "" {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("checkpoint %s of an unfinished rebalance exists, pass --resume to continue it, or remove it", path)
+		}
+	} else if rebalanceResume {
+		return errors.New("no checkpoint to resume, home dir is unknown, pass --checkpoint")
+	}
+
+	nodes, err := pod.ClusterNodes(ctx)
+	if err != nil {
+		return err
+	}
+	masters := make([]*redis.RedisNode, 0)
+	names := make(map[string]*redis.RedisNode)
+	for _, n := range nodes {
+		if n.IsMaster() && !n.HasFlag("fail") {
+			masters = append(masters, n)
+			names[n.Pod.Name] = n
+		}
+	}
+	sort.Slice(masters, func(i, j int) bool { return masters[i].Pod.Name < masters[j].Pod.Name })
+	m := pod.NewSlotMigrator(masters, rebalancePipeline, rebalanceTimeout, rebalanceReplace)
+	defer m.Close()
+	m.MaxKeysPerSec = rebalanceMaxKeysPerSec
+
+	var moves []*redis.SlotMove
+	if cp != nil {
+		if moves, err = cp.Resolve(nodes); err != nil {
+			return err
+		}
+		inPlan := make(map[int]bool)
+		for _, mv := range moves {
+			inPlan[mv.Slot] = true
+		}
+		for _, s := range redis.OpenSlots(nodes) {
+			if !inPlan[s.Slot] {
+				return fmt.Errorf("slot %d is open but not in checkpoint, fix it before rebalance", s.Slot)
+			}
+		}
+		fmt.Printf("resume rebalance by %s from %s, %d of %d slots to move\n", cp.By, path, cp.Pending(), len(moves))
+		if rebalanceSimulate {
+			for i, mv := range moves {
+				if !cp.IsDone(mv.Slot) {
+					fmt.Printf("[%d/%d] %s\n", i+1, len(moves), mv)
+				}
+			}
+			return nil
+		}
+	} else {
+		if openSlots := redis.OpenSlots(nodes); len(openSlots) > 0 {
+			return fmt.Errorf("cluster has %d open slots, fix them before rebalance", len(openSlots))
+		}
+		nodeWeights := make(map[string]int)
+		for p, w := range weights {
+			n, ok := names[p]
+			if !ok {
+				return fmt.Errorf("can't find master pod %s in redis cluster nodes", p)
+			}
+			if nodeWeights[n.ID], err = strconv.Atoi(w); err != nil {
+				return fmt.Errorf("wrong weight %s of %s", w, p)
+			}
+		}
+		slotWeights, err := m.MeasureSlots(ctx, rebalanceBy, rebalanceMemorySample)
+		if err != nil {
+			return err
+		}
+		plan, err := redis.PlanBalance(masters, slotWeights, nodeWeights, rebalanceUseEmptyMaster, rebalanceThreshold)
+		if err != nil {
+			return err
+		}
+		format := func(v int64) string {
+			if rebalanceBy == redis.BalanceByMemory {
+				return common.FormatBytes(v)
+			}
+			return strconv.FormatInt(v, 10)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "MASTER\tBEFORE (%s)\tAFTER (%s)\n", rebalanceBy, rebalanceBy)
+		for _, n := range masters {
+			if before, ok := plan.Before[n.ID]; ok {
+				fmt.Fprintf(w, "%s\t%s\t%s\n", n.Pod.Name, format(before), format(plan.After[n.ID]))
+			}
+		}
+		w.Flush()
+		fmt.Printf("%d slots to move\n", len(plan.Moves))
+		if rebalanceSimulate || len(plan.Moves) == 0 {
+			return nil
+		}
+		moves = plan.Moves
+		if path != "" {
+			if cp, err = redis.NewCheckpoint(path, rebalanceBy, moves); err != nil {
+				return err
+			}
+		}
+	}
+
+	tty := terminal.IsTerminal(int(os.Stdout.Fd()))
+	started := time.Now()
+	var total int64
+	m.Progress = func(p *redis.SlotProgress) {
+		line := fmt.Sprintf("[%d/%d] %s: %d/%d keys in %s", p.Index, p.Total, p.Move, p.Keys, p.Expected, p.Elapsed.Round(time.Millisecond))
+		if !p.Done {
+			if tty {
+				fmt.Printf("\r%s\033[K", line)
+			}
+			return
+		}
+		total += p.Keys
+		line += fmt.Sprintf(", %.0f keys/s overall", float64(total)/time.Since(started).Seconds())
+		if tty {
+			line = "\r" + line + "\033[K"
+		}
+		fmt.Println(line)
+	}
+	if err := m.Run(ctx, moves, cp); err != nil {
+		if cp != nil {
+			return fmt.Errorf("%v, finished slots are saved in %s, rerun with --resume to continue", err, path)
+		}
+		return err
+	}
+	if cp != nil {
+		return cp.Remove()
+	}
+	return nil
+}
+
 func init() {
 	rebalanceCmd.Flags().StringVar(&rebalanceWeight, "weight", "", "set rebalance weights for pods, eg: rc-0=1,rc-1=2")
 	rebalanceCmd.Flags().BoolVar(&rebalanceUseEmptyMaster, "use-empty-masters", false, "assign slots to empty master")
@@ -81,5 +246,18 @@ func init() {
 	rebalanceCmd.Flags().IntVar(&rebalancePipeline,"pipeline", 10, "migrate keys batch size")
 	rebalanceCmd.Flags().IntVar(&rebalanceThreshold,"threshold", 2, "do rebalance if slots difference percentage is over threshold")
 	rebalanceCmd.Flags().BoolVar(&rebalanceReplace,"replace", false, "if key existed in target node, do replace")
+	rebalanceCmd.Flags().StringVar(&rebalanceBy, "by", "", "balance slots, keys or memory of masters, slots are migrated natively with progress instead of redis-cli")
+	rebalanceCmd.Flags().IntVar(&rebalanceMemorySample, "memory-sample", 5, "keys sampled per slot by memory usage to estimate slot memory, with --by memory")
+	rebalanceCmd.Flags().IntVar(&rebalanceMaxKeysPerSec, "max-keys-per-sec", 0, "throttle native migration, 0 is unlimited")
+	rebalanceCmd.Flags().StringVar(&rebalanceCheckpoint, "checkpoint", "", "checkpoint file of native migration (default ~/.kuberc/rebalance/<context>_<namespace>_<statefulset>.json)")
+	rebalanceCmd.Flags().BoolVar(&rebalanceResume, "resume", false, "continue native migration interrupted before from checkpoint")
+	audit(rebalanceCmd, func(args []string) string {
+		// dry run changes nothing
+		if rebalanceSimulate {
+			return ""
+		}
+		return firstArg(args)
+	})
+	rebalanceCmd.ValidArgsFunction = completeRedisPods(1)
 	rootCmd.AddCommand(rebalanceCmd)
 }
@@ -22,21 +22,33 @@ THE SOFTWARE.
 package main
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
-	"github.com/mitchellh/go-homedir"
+	"github.com/monsterxx03/kuberc/pkg/common"
 	"github.com/monsterxx03/kuberc/pkg/redis"
 	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"os"
 	"sort"
+	"strings"
+	"sync"
 )
 
-var cfgFile string
+var kubeFlags = genericclioptions.NewConfigFlags(true)
+var ctx context.Context
+var cancel context.CancelFunc
 var namespace string
 var containerName string
 var redisPort int
+var redisUser string
+var redisPassword string
+var redisPasswordSecret string
+var tlsOpts common.TLSOptions
+var conn *common.ConnConfig
 var restcfg *restclient.Config
 var clientset *kubernetes.Clientset
 
@@ -48,29 +60,65 @@ var rootCmd = &cobra.Command{
 replace redis nodes on k8s.
 `,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		if os.Getenv("KUBECONFIG") != "" {
-			cfgFile = os.Getenv("KUBECONFIG")
-		} else {
-			cfgFile, err = homedir.Expand(cfgFile)
-			if err != nil {
-				return err
-			}
-		}
-		restcfg, err = clientcmd.BuildConfigFromFlags("", cfgFile)
-		if err != nil {
-			return err
-		}
-		clientset, err = kubernetes.NewForConfig(restcfg)
-		if err != nil {
-			return err
+		// completion builds clients only when completing names
+		if isCompletion(cmd) {
+			return nil
 		}
-		return nil
+		return initClients()
 	},
 }
 
+var clientsOnce sync.Once
+var clientsErr error
+
+// initClients builds k8s clients, context and redis connection config from flags, only once
+func initClients() error {
+	clientsOnce.Do(func() { clientsErr = buildClients() })
+	return clientsErr
+}
+
+// isCompletion is true for completion script generation and the hidden completion request commands
+func isCompletion(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	return false
+}
+
+func buildClients() error {
+	var err error
+	restcfg, err = kubeFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	// namespace from flag, or from current context in kubeconfig
+	namespace, _, err = kubeFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	clientset, err = kubernetes.NewForConfig(restcfg)
+	if err != nil {
+		return err
+	}
+	timeout, err := common.ParseTimeout(*kubeFlags.Timeout)
+	if err != nil {
+		return err
+	}
+	ctx, cancel = common.NewContext(timeout)
+	conn, err = common.NewConnConfig(ctx, redisUser, redisPassword, redisPasswordSecret, &tlsOpts, namespace, clientset)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if cancel != nil {
+		cancel()
+	}
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -78,23 +126,36 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().IntVarP(&redisPort, "port", "p", 6379, "redis port")
-	rootCmd.PersistentFlags().StringVarP(&namespace, "namespace", "n", "default", "namespace")
 	rootCmd.PersistentFlags().StringVarP(&containerName, "container", "c", "", "container name")
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "kubeconfig used for kubectl, will try to load from $KUBECONFIG first")
+	kubeFlags.AddFlags(rootCmd.PersistentFlags())
+	rootCmd.PersistentFlags().StringVar(kubeFlags.KubeConfig, "config", "", "kubeconfig used for kubectl")
+	rootCmd.PersistentFlags().MarkDeprecated("config", "use --kubeconfig instead")
+	rootCmd.PersistentFlags().StringVar(&redisUser, "redis-user", "", "redis acl user")
+	rootCmd.PersistentFlags().StringVar(&redisPassword, "password", "", "redis password")
+	rootCmd.PersistentFlags().StringVar(&redisPasswordSecret, "password-secret", "", "load redis password from secret in namespace, eg: redis-auth/password")
+	rootCmd.PersistentFlags().BoolVar(&tlsOpts.Enabled, "tls", false, "connect with tls")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.CACert, "cacert", "", "ca cert file path in redis container, for redis-cli")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.Cert, "cert", "", "client cert file path in redis container, for redis-cli")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.Key, "key", "", "client key file path in redis container, for redis-cli")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.SNI, "sni", "", "server name indication for tls")
+	rootCmd.PersistentFlags().BoolVar(&tlsOpts.Insecure, "insecure", false, "skip tls server certificate verification")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.Secret, "tls-secret", "", "secret in namespace with ca.crt/tls.crt/tls.key, for connections through port-forward")
+	rootCmd.PersistentFlags().StringVar(&auditLog, "audit-log", common.DefaultAuditLogPath(), "append records of mutating commands to this file, empty to disable")
+	rootCmd.PersistentFlags().BoolVar(&auditEvents, "audit-events", true, "create k8s events on pods affected by mutating commands")
 }
 
 func getClusterPods(podname string, all bool) ([]*redis.RedisPod, error) {
-	pod, err := redis.NewRedisPod(podname, containerName, namespace, redisPort, clientset, restcfg)
+	pod, err := redis.NewRedisPod(ctx, podname, containerName, namespace, redisPort, conn, clientset, restcfg)
 	if err != nil {
 		return nil, err
 	}
 	pods := make([]*redis.RedisPod, 0)
 	if all {
-		if nodes, err := pod.ClusterNodes(); err != nil {
+		if nodes, err := pod.ClusterNodes(ctx); err != nil {
 			return nil, err
 		} else {
 			for _, n := range nodes {
-				pods = append(pods, redis.NewRedisPodWithPod(n.Pod, containerName, redisPort, clientset, restcfg))
+				pods = append(pods, redis.NewRedisPodWithPod(n.Pod, containerName, redisPort, conn, clientset, restcfg))
 			}
 		}
 	} else {
@@ -106,6 +167,32 @@ func getClusterPods(podname string, all bool) ([]*redis.RedisPod, error) {
 	return pods, nil
 }
 
+// confirm asks user to type yes to continue
+func confirm(msg string) bool {
+	fmt.Printf("%s (type 'yes' to continue): ", msg)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	return strings.TrimSpace(answer) == "yes"
+}
+
+// preflight prints issues, refuses blocking ones unless forced, asks for confirmation unless yes.
+// always asks for confirmation when destructive even without issues.
+func preflight(issues []*redis.PreflightIssue, destructive, yes, force bool, forceFlag string) error {
+	blocked := false
+	for _, i := range issues {
+		fmt.Println(i)
+		if i.Level == redis.PreflightBlock {
+			blocked = true
+		}
+	}
+	if blocked && !force {
+		return fmt.Errorf("refused by preflight checks, pass %s to override", forceFlag)
+	}
+	if (len(issues) > 0 || destructive) && !yes && !confirm("Continue?") {
+		return errors.New("aborted")
+	}
+	return nil
+}
+
 func main() {
 	Execute()
 }
@@ -0,0 +1,207 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	scaleInRemove int
+	scaleInYes    bool
+	scaleInForce  bool
+)
+
+// scaleInCmd represents the scale-in command
+var scaleInCmd = &cobra.Command{
+	Use:   "scale-in --statefulset <sts> --remove <n>",
+	Short: "Drain slots of highest ordinal pods, remove them from cluster and shrink statefulset",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if scaleStatefulSet == "" {
+			return errors.New("--statefulset is required")
+		}
+		sts, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, scaleStatefulSet, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current := int(*sts.Spec.Replicas)
+		if scaleInRemove <= 0 || scaleInRemove >= current {
+			return fmt.Errorf("remove must be in [1, %d)", current)
+		}
+		target := current - scaleInRemove
+		// statefulset always deletes pods with highest ordinals
+		removed := make(map[string]bool)
+		removedPods := make([]string, 0, scaleInRemove)
+		for i := target; i < current; i++ {
+			name := common.StatefulSetPodName(scaleStatefulSet, i)
+			removed[name] = true
+			removedPods = append(removedPods, name)
+		}
+		entry, err := redis.NewRedisPod(ctx, common.StatefulSetPodName(scaleStatefulSet, 0), containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		nodes, err := entry.ClusterNodes(ctx)
+		if err != nil {
+			return err
+		}
+		removedMasters := make(map[string]*redis.RedisNode)
+		remainingMasters := make([]*redis.RedisNode, 0)
+		for _, n := range nodes {
+			if !n.IsMaster() {
+				continue
+			}
+			if removed[n.Pod.Name] {
+				removedMasters[n.ID] = n
+			} else {
+				remainingMasters = append(remainingMasters, n)
+			}
+		}
+		if len(remainingMasters) == 0 {
+			return errors.New("no master left after scale-in")
+		}
+		byID := make(map[string]*redis.RedisNode)
+		for _, n := range nodes {
+			byID[n.ID] = n
+		}
+		reassign := redis.ReassignSlaves(nodes, removed)
+		fmt.Printf("scale statefulset %s from %d to %d pods:\n", scaleStatefulSet, current, target)
+		for _, name := range removedPods {
+			var node *redis.RedisNode
+			for _, n := range nodes {
+				if n.Pod.Name == name {
+					node = n
+				}
+			}
+			switch {
+			case node == nil:
+				fmt.Printf("  remove %s, not in cluster\n", name)
+			case node.IsMaster():
+				fmt.Printf("  remove %s, master owning %d slots, move them to remaining masters\n", name, node.SlotsCount())
+			default:
+				fmt.Printf("  remove %s, slave of %s\n", name, nodeName(byID[node.MasterID]))
+			}
+		}
+		for _, n := range nodes {
+			if m, ok := reassign[n]; ok {
+				fmt.Printf("  make %s slave of %s\n", n.Pod.Name, m.Pod.Name)
+			}
+		}
+		if err := preflight(redis.ScaleInPreflight(nodes, removed), true, scaleInYes, scaleInForce, "--force"); err != nil {
+			return err
+		}
+		// nodes are reloaded after rebalance, reassign is keyed by nodes of plan
+		planned := nodes
+
+		fmt.Printf("[1/5] move slots of masters in %v to remaining masters\n", removedPods)
+		weights := make(map[string]string)
+		for _, n := range removedMasters {
+			if n.SlotsCount() > 0 {
+				weights[n.Pod.Name] = "0"
+			}
+		}
+		if len(weights) > 0 {
+			// redis-cli output is streamed to stdout
+			if _, err := entry.ClusterRebalance(ctx, weights, false, scaleTimeout, false, scalePipeline, 1, false); err != nil {
+				return err
+			}
+			if nodes, err = entry.ClusterNodes(ctx); err != nil {
+				return err
+			}
+			for _, n := range nodes {
+				if _, ok := removedMasters[n.ID]; ok && (n.SlotsCount() > 0 || len(n.Migrating) > 0 || len(n.Importing) > 0) {
+					return fmt.Errorf("%s still owns slots after rebalance, check with `rc slots`", n.Pod.Name)
+				}
+			}
+		}
+
+		// slaves of removed masters on remaining pods are moved to the master with fewest slaves
+		for _, n := range planned {
+			m, ok := reassign[n]
+			if !ok {
+				continue
+			}
+			fmt.Printf("[2/5] make %s slave of %s\n", n.Pod.Name, m.Pod.Name)
+			p := redis.NewRedisPodWithPod(n.Pod, containerName, redisPort, conn, clientset, restcfg)
+			res, err := p.ClusterReplicate(ctx, m.ID)
+			if err != nil {
+				return err
+			}
+			if res != "" {
+				fmt.Println(res)
+			}
+		}
+
+		// remove slaves before masters, so no slave points to a forgotten master
+		for _, master := range []bool{false, true} {
+			for _, n := range nodes {
+				if !removed[n.Pod.Name] || n.IsMaster() != master {
+					continue
+				}
+				fmt.Printf("[3/5] delete %s from cluster\n", n.Pod.Name)
+				res, err := entry.ClusterDelNode(ctx, n.ID)
+				if err != nil {
+					return err
+				}
+				if res != "" {
+					fmt.Println(res)
+				}
+			}
+		}
+		if err := entry.WaitClusterConsistent(ctx, scaleWaitTimeout); err != nil {
+			return err
+		}
+
+		fmt.Printf("[4/5] scale statefulset %s from %d to %d\n", scaleStatefulSet, current, target)
+		if err := common.ScaleStatefulSet(ctx, clientset, namespace, scaleStatefulSet, int32(target)); err != nil {
+			return err
+		}
+		fmt.Printf("[5/5] wait for pods %v to be deleted\n", removedPods)
+		return common.WaitPodsDeleted(ctx, clientset, namespace, removedPods, scaleWaitTimeout)
+	},
+}
+
+func init() {
+	scaleInCmd.Flags().StringVar(&scaleStatefulSet, "statefulset", "", "statefulset of redis cluster")
+	scaleInCmd.Flags().IntVar(&scaleInRemove, "remove", 1, "number of pods (masters and slaves) to remove, pods with highest ordinals are removed")
+	scaleInCmd.Flags().BoolVar(&scaleInYes, "yes", false, "don't ask for confirmation")
+	scaleInCmd.Flags().BoolVar(&scaleInForce, "force", false, "scale in even if preflight checks refuse, eg: a node is failing")
+	scaleInCmd.Flags().DurationVar(&scaleWaitTimeout, "wait-timeout", 10*time.Minute, "max time to wait for cluster consistent and pods deleted")
+	scaleInCmd.Flags().IntVar(&scalePipeline, "pipeline", 10, "migrate keys batch size during rebalance")
+	scaleInCmd.Flags().IntVar(&scaleTimeout, "timeout", 60000, "migrate timeout in milliseconds in single batch during rebalance")
+	audit(scaleInCmd, func(args []string) string {
+		if scaleStatefulSet == "" {
+			return ""
+		}
+		return common.StatefulSetPodName(scaleStatefulSet, 0)
+	})
+	scaleInCmd.RegisterFlagCompletionFunc("statefulset", completeStatefulSets)
+	rootCmd.AddCommand(scaleInCmd)
+}
@@ -0,0 +1,171 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	scaleStatefulSet string
+	scaleWaitTimeout time.Duration
+	scalePipeline    int
+	scaleTimeout     int
+	scaleThreshold   int
+	scaleOutMasters  int
+	scaleOutReplicas int
+)
+
+// scaleOutCmd represents the scale-out command
+var scaleOutCmd = &cobra.Command{
+	Use:   "scale-out --statefulset <sts> --masters <n>",
+	Short: "Grow redis statefulset, join new pods into cluster and rebalance",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if scaleStatefulSet == "" {
+			return errors.New("--statefulset is required")
+		}
+		if scaleOutMasters <= 0 || scaleOutReplicas < 0 {
+			return errors.New("masters must > 0, replicas-per-master must >= 0")
+		}
+		sts, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, scaleStatefulSet, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		current := int(*sts.Spec.Replicas)
+		if current == 0 {
+			return fmt.Errorf("statefulset %s has no replicas", scaleStatefulSet)
+		}
+		target := current + scaleOutMasters*(1+scaleOutReplicas)
+		entry, err := redis.NewRedisPod(ctx, common.StatefulSetPodName(scaleStatefulSet, 0), containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		members, err := clusterPodNames(entry)
+		if err != nil {
+			return err
+		}
+		// refuse to scale again if a previous scale-out didn't finish
+		for i := 0; i < current; i++ {
+			if name := common.StatefulSetPodName(scaleStatefulSet, i); !members[name] {
+				return fmt.Errorf("pod %s is not in cluster, finish it with add-node first", name)
+			}
+		}
+
+		fmt.Printf("[1/5] scale statefulset %s from %d to %d\n", scaleStatefulSet, current, target)
+		if err := common.ScaleStatefulSet(ctx, clientset, namespace, scaleStatefulSet, int32(target)); err != nil {
+			return err
+		}
+
+		newPods := make([]string, 0, target-current)
+		for i := current; i < target; i++ {
+			newPods = append(newPods, common.StatefulSetPodName(scaleStatefulSet, i))
+		}
+		fmt.Printf("[2/5] wait for pods %v to be ready\n", newPods)
+		if err := common.WaitPodsReady(ctx, clientset, namespace, newPods, scaleWaitTimeout); err != nil {
+			return err
+		}
+
+		masters := make([]*redis.RedisPod, 0, scaleOutMasters)
+		for i := 0; i < scaleOutMasters; i++ {
+			p, err := redis.NewRedisPod(ctx, newPods[i], containerName, namespace, redisPort, conn, clientset, restcfg)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("[3/5] add %s as master\n", p.GetName())
+			res, err := entry.ClusterAddNode(ctx, p, false)
+			if err != nil {
+				return err
+			}
+			if res != "" {
+				fmt.Println(res)
+			}
+			masters = append(masters, p)
+		}
+		if err := entry.WaitClusterConsistent(ctx, scaleWaitTimeout); err != nil {
+			return err
+		}
+
+		for i, m := range masters {
+			for j := 0; j < scaleOutReplicas; j++ {
+				p, err := redis.NewRedisPod(ctx, newPods[scaleOutMasters+i*scaleOutReplicas+j], containerName, namespace, redisPort, conn, clientset, restcfg)
+				if err != nil {
+					return err
+				}
+				fmt.Printf("[4/5] add %s as slave of %s\n", p.GetName(), m.GetName())
+				res, err := m.ClusterAddNode(ctx, p, true)
+				if err != nil {
+					return err
+				}
+				if res != "" {
+					fmt.Println(res)
+				}
+			}
+		}
+		if err := entry.WaitClusterConsistent(ctx, scaleWaitTimeout); err != nil {
+			return err
+		}
+
+		fmt.Println("[5/5] rebalance slots to new masters")
+		// redis-cli output is streamed to stdout
+		_, err = entry.ClusterRebalance(ctx, nil, true, scaleTimeout, false, scalePipeline, scaleThreshold, false)
+		return err
+	},
+}
+
+// clusterPodNames returns names of pods in cluster
+func clusterPodNames(p *redis.RedisPod) (map[string]bool, error) {
+	nodes, err := p.ClusterNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]bool)
+	for _, n := range nodes {
+		names[n.Pod.Name] = true
+	}
+	return names, nil
+}
+
+func init() {
+	scaleOutCmd.Flags().StringVar(&scaleStatefulSet, "statefulset", "", "statefulset of redis cluster")
+	scaleOutCmd.Flags().IntVar(&scaleOutMasters, "masters", 1, "number of masters to add")
+	scaleOutCmd.Flags().IntVar(&scaleOutReplicas, "replicas-per-master", 0, "number of slaves for every new master")
+	scaleOutCmd.Flags().DurationVar(&scaleWaitTimeout, "wait-timeout", 10*time.Minute, "max time to wait for pods ready and cluster consistent")
+	scaleOutCmd.Flags().IntVar(&scalePipeline, "pipeline", 10, "migrate keys batch size during rebalance")
+	scaleOutCmd.Flags().IntVar(&scaleTimeout, "timeout", 60000, "migrate timeout in milliseconds in single batch during rebalance")
+	scaleOutCmd.Flags().IntVar(&scaleThreshold, "threshold", 2, "rebalance if slots difference percentage is over threshold")
+	audit(scaleOutCmd, func(args []string) string {
+		if scaleStatefulSet == "" {
+			return ""
+		}
+		return common.StatefulSetPodName(scaleStatefulSet, 0)
+	})
+	scaleOutCmd.RegisterFlagCompletionFunc("statefulset", completeStatefulSets)
+	rootCmd.AddCommand(scaleOutCmd)
+}
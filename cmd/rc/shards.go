@@ -0,0 +1,75 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+)
+
+// shardsCmd represents the shards command
+var shardsCmd = &cobra.Command{
+	Use:   "shards <pod>",
+	Short: "Get cluster shards info (redis >= 7.0)",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		shards, err := p.ClusterShards(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "slots\tpod\trole\tendpoint\tport\ttls-port\toffset\thealth\t")
+		for _, s := range shards {
+			slots := make([]string, 0, len(s.Slots))
+			for _, r := range s.Slots {
+				slots = append(slots, r.String())
+			}
+			for i, n := range s.Nodes {
+				podName := "?"
+				if n.Pod != nil {
+					podName = n.Pod.Name
+				}
+				slotsCol := ""
+				if i == 0 {
+					slotsCol = strings.Join(slots, ",")
+				}
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d\t%d\t%d\t%s\t\n", slotsCol, podName, n.Role, n.Endpoint, n.Port, n.TLSPort, n.ReplicationOffset, n.Health)
+			}
+		}
+		w.Flush()
+		return nil
+	},
+}
+
+func init() {
+	shardsCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(shardsCmd)
+}
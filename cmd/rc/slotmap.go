@@ -0,0 +1,186 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var (
+	slotmapWidth        int
+	slotmapSlotsPerCell int
+	slotmapOutput       string
+	slotmapCellSize     int
+	slotmapNoColor      bool
+)
+
+// slotmapPalette are distinct ansi 256 colors of masters
+var slotmapPalette = []int{39, 208, 70, 170, 226, 33, 203, 43, 129, 214, 99, 118, 160, 31, 178, 63}
+
+// slotmapCmd represents the slotmap command
+var slotmapCmd = &cobra.Command{
+	Use:   "slotmap <pod>",
+	Short: "Render all slots as a grid coloured by owning master",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if slotmapSlotsPerCell <= 0 || slotmapWidth <= 0 || slotmapCellSize <= 0 {
+			return errors.New("width, slots-per-cell and cell-size should > 0")
+		}
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		nodes, err := p.ClusterNodes(ctx)
+		if err != nil {
+			return err
+		}
+		m := redis.NewSlotMap(nodes)
+		switch slotmapOutput {
+		case "":
+		case "svg":
+			return m.WriteSVG(os.Stdout, slotmapCellSize)
+		case "html":
+			return m.WriteHTML(os.Stdout, fmt.Sprintf("slot map of %s/%s", namespace, args[0]), slotmapCellSize)
+		default:
+			return fmt.Errorf("unknown output %s, should be svg or html", slotmapOutput)
+		}
+		color := !slotmapNoColor && terminal.IsTerminal(int(os.Stdout.Fd()))
+		index := make(map[*redis.RedisNode]int)
+		for i, n := range m.Masters {
+			index[n] = i
+		}
+		// without color every cell is as wide as master markers, so they line up
+		width := 1
+		if !color && len(m.Masters) > 26 {
+			width = 2
+		}
+		uncovered, openMark := "x", "!"
+		if !color {
+			// lower case x is mixed cell of 24th master
+			uncovered, openMark = strings.Repeat("-", width), strings.Repeat("!", width)
+		}
+		mark := func(n *redis.RedisNode, mixed bool) string {
+			i := index[n]
+			if color {
+				c := "█"
+				if mixed {
+					c = "▒"
+				}
+				return fmt.Sprintf("\033[38;5;%dm%s\033[0m", slotmapPalette[i%len(slotmapPalette)], c)
+			}
+			c := slotmapMarker(i, width)
+			if mixed {
+				c = strings.ToLower(c)
+			}
+			return c
+		}
+
+		fmt.Printf("%d slots per cell, %d cells per row\n", slotmapSlotsPerCell, slotmapWidth)
+		var b strings.Builder
+		for start := 0; start < redis.SlotsNum; start += slotmapSlotsPerCell {
+			if start/slotmapSlotsPerCell%slotmapWidth == 0 {
+				if start > 0 {
+					b.WriteString("\n")
+				}
+				fmt.Fprintf(&b, "%5d ", start)
+			}
+			end := start + slotmapSlotsPerCell
+			if end > redis.SlotsNum {
+				end = redis.SlotsNum
+			}
+			counts := make(map[*redis.RedisNode]int)
+			open := false
+			for i := start; i < end; i++ {
+				counts[m.Owners[i]]++
+				if _, ok := m.Open[i]; ok {
+					open = true
+				}
+			}
+			var major *redis.RedisNode
+			for n, c := range counts {
+				if n != nil && (major == nil || c > counts[major] || (c == counts[major] && index[n] < index[major])) {
+					major = n
+				}
+			}
+			switch {
+			case counts[nil] > 0:
+				if color {
+					b.WriteString("\033[31mx\033[0m")
+				} else {
+					b.WriteString(uncovered)
+				}
+			case open:
+				if color {
+					fmt.Fprintf(&b, "\033[1;38;5;%dm!\033[0m", slotmapPalette[index[major]%len(slotmapPalette)])
+				} else {
+					b.WriteString(openMark)
+				}
+			default:
+				b.WriteString(mark(major, len(counts) > 1))
+			}
+		}
+		fmt.Println(b.String())
+
+		fmt.Println()
+		for _, n := range m.Masters {
+			fmt.Printf("%s %s: %d slots, %d ranges\n", mark(n, false), n.Pod.Name, n.SlotsCount(), m.Fragments(n))
+		}
+		if len(m.Masters) > 0 {
+			fmt.Printf("%s mixed owners (majority shown), %s open slot, %s uncovered slot\n", mark(m.Masters[0], true), openMark, uncovered)
+		}
+		if uncovered := m.Uncovered(); uncovered > 0 {
+			fmt.Printf("%d slots are not covered\n", uncovered)
+		}
+		for _, s := range redis.OpenSlots(nodes) {
+			fmt.Printf("slot %d: %s -> %s\n", s.Slot, nodeName(s.Source), nodeName(s.Target))
+		}
+		return nil
+	},
+}
+
+// slotmapMarker returns upper case marker of i-th master without color, width letters long:
+// A-Z, or AA-ZZ for clusters over 26 masters
+func slotmapMarker(i, width int) string {
+	b := make([]byte, width)
+	for j := width - 1; j >= 0; j-- {
+		b[j] = byte('A' + i%26)
+		i /= 26
+	}
+	return string(b)
+}
+
+func init() {
+	slotmapCmd.Flags().IntVar(&slotmapWidth, "width", 64, "cells per row")
+	slotmapCmd.Flags().IntVar(&slotmapSlotsPerCell, "slots-per-cell", 16, "slots rendered in a single cell")
+	slotmapCmd.Flags().StringVarP(&slotmapOutput, "output", "o", "", "export as svg or html instead of rendering in terminal")
+	slotmapCmd.Flags().IntVar(&slotmapCellSize, "cell-size", 6, "pixels of a slot in svg/html")
+	slotmapCmd.Flags().BoolVar(&slotmapNoColor, "no-color", false, "use letters instead of colors")
+	slotmapCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(slotmapCmd)
+}
@@ -37,11 +37,11 @@ var slotsCmd = &cobra.Command{
 	Short: "Get cluster slots info",
 	Args: cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		p, err := redis.NewRedisPod(args[0], containerName, namespace, redisPort, clientset, restcfg)
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		if slots, err := p.ClusterSlots(); err != nil {
+		if slots, err := p.ClusterSlots(ctx); err != nil {
 			return err
 		} else {
 			sort.Slice(slots, func(i, j int) bool {
@@ -58,11 +58,25 @@ var slotsCmd = &cobra.Command{
 			}
 			w.Flush()
 		}
+		nodes, err := p.ClusterNodes(ctx)
+		if err != nil {
+			return err
+		}
+		if openSlots := redis.OpenSlots(nodes); len(openSlots) > 0 {
+			fmt.Println()
+			w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+			fmt.Fprintln(w, "open slot\tmigrating\timporting\t")
+			for _, s := range openSlots {
+				fmt.Fprintf(w, "%d\t%s\t%s\t\n", s.Slot, nodeName(s.Source), nodeName(s.Target))
+			}
+			w.Flush()
+		}
 		return nil
 	},
 }
 
 func init() {
+	slotsCmd.ValidArgsFunction = completeRedisPods(1)
 	rootCmd.AddCommand(slotsCmd)
 
 }
@@ -0,0 +1,168 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/redis"
+	"github.com/spf13/cobra"
+)
+
+var (
+	slowlogSince time.Duration
+	slowlogTop   int
+	slowlogReset bool
+)
+
+type slowlogRecord struct {
+	*redis.SlowlogEntry
+	node *redis.RedisNode
+}
+
+type slowlogGroup struct {
+	command string
+	count   int
+	total   time.Duration
+	max     time.Duration
+}
+
+// slowlogCmd represents the slowlog command
+var slowlogCmd = &cobra.Command{
+	Use:   "slowlog <pod>",
+	Short: "Aggregate slowlog from all redis nodes",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		p, err := redis.NewRedisPod(ctx, args[0], containerName, namespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			return err
+		}
+		nodes, err := p.ClusterNodes(ctx)
+		if err != nil {
+			return err
+		}
+		records := make([]*slowlogRecord, 0)
+		for _, n := range nodes {
+			pod := redis.NewRedisPodWithPod(n.Pod, containerName, redisPort, conn, clientset, restcfg)
+			entries, err := pod.SlowlogGet(ctx)
+			if err != nil {
+				return err
+			}
+			for _, e := range entries {
+				if slowlogSince > 0 && time.Since(e.Time) > slowlogSince {
+					continue
+				}
+				records = append(records, &slowlogRecord{SlowlogEntry: e, node: n})
+			}
+		}
+		sort.Slice(records, func(i, j int) bool {
+			return records[i].Time.After(records[j].Time)
+		})
+		shown := records
+		if slowlogTop > 0 && len(shown) > slowlogTop {
+			shown = shown[:slowlogTop]
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "time\tpod\trole\thost\tduration\tclient\tcommand\t")
+		for _, r := range shown {
+			role := "slave"
+			if r.node.IsMaster() {
+				role = "master"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t\n", r.Time.Format("2006-01-02 15:04:05"), r.node.Pod.Name, role,
+				r.node.Pod.Spec.NodeName, r.Duration, r.ClientAddr, truncate(strings.Join(r.Args, " "), 80))
+		}
+		w.Flush()
+
+		groups := make(map[string]*slowlogGroup)
+		for _, r := range records {
+			name := ""
+			if len(r.Args) > 0 {
+				name = strings.ToLower(r.Args[0])
+			}
+			g, ok := groups[name]
+			if !ok {
+				g = &slowlogGroup{command: name}
+				groups[name] = g
+			}
+			g.count++
+			g.total += r.Duration
+			if r.Duration > g.max {
+				g.max = r.Duration
+			}
+		}
+		sorted := make([]*slowlogGroup, 0, len(groups))
+		for _, g := range groups {
+			sorted = append(sorted, g)
+		}
+		sort.Slice(sorted, func(i, j int) bool {
+			return sorted[i].total > sorted[j].total
+		})
+		fmt.Println()
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "command\tcount\ttotal\tavg\tmax\t")
+		for _, g := range sorted {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t\n", g.command, g.count, g.total, g.total/time.Duration(g.count), g.max)
+		}
+		w.Flush()
+
+		if slowlogReset {
+			for _, n := range nodes {
+				pod := redis.NewRedisPodWithPod(n.Pod, containerName, redisPort, conn, clientset, restcfg)
+				if _, err := pod.SlowlogReset(ctx); err != nil {
+					return err
+				}
+			}
+			fmt.Println("slowlog reset on all nodes")
+		}
+		return nil
+	},
+}
+
+// truncate cuts s to at most n characters
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
+
+func init() {
+	slowlogCmd.Flags().DurationVar(&slowlogSince, "since", 0, "only show entries newer than this, eg: 10m")
+	slowlogCmd.Flags().IntVar(&slowlogTop, "top", 50, "show latest N entries, 0 means all")
+	slowlogCmd.Flags().BoolVar(&slowlogReset, "reset", false, "run slowlog reset on all nodes afterwards")
+	audit(slowlogCmd, func(args []string) string {
+		if slowlogReset {
+			return args[0]
+		}
+		return ""
+	})
+	slowlogCmd.ValidArgsFunction = completeRedisPods(1)
+	rootCmd.AddCommand(slowlogCmd)
+}
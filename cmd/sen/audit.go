@@ -0,0 +1,128 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+	"github.com/monsterxx03/kuberc/pkg/sentinel"
+	"github.com/spf13/cobra"
+	"k8s.io/klog/v2"
+)
+
+var auditLog string
+var auditEvents bool
+
+// audit records every run of a mutating command c: k8s events on affected pods and a line in audit log,
+// with pod => state returned by snapshot before and after.
+func audit(c *cobra.Command, snapshot func(ctx context.Context, args []string) map[string]string) {
+	run := c.RunE
+	c.RunE = func(cmd *cobra.Command, args []string) error {
+		rec := &common.AuditRecord{Time: time.Now(), User: common.KubeUser(kubeFlags), Command: cmd.CommandPath(),
+			Args: common.AuditArgs(cmd.Flags(), args), Namespace: sentinelNamespace}
+		before := snapshot(ctx, args)
+		err := run(cmd, args)
+		rec.SetResult(err)
+
+		// ctx may be cancelled or expired when command fails
+		actx, acancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer acancel()
+		after := snapshot(actx, args)
+		affected := make(map[string]bool)
+		for p, v := range before {
+			if after[p] != v {
+				affected[p] = true
+			}
+		}
+		for p, v := range after {
+			if before[p] != v {
+				affected[p] = true
+			}
+		}
+		// args are usually pods, missing ones are skipped in PodRefs
+		for _, a := range args {
+			affected[a] = true
+		}
+		names := make([]string, 0, len(affected))
+		for p := range affected {
+			names = append(names, p)
+		}
+		if before != nil {
+			rec.Before = before
+		}
+		if after != nil {
+			rec.After = after
+		}
+		common.NewAuditor(auditLog, auditEvents, clientset).Record(actx, rec, common.PodRefs(actx, clientset, sentinelNamespace, names))
+		return err
+	}
+}
+
+// masterSnapshot returns master pod of <master-name> seen by <sentinel-pod>
+func masterSnapshot(ctx context.Context, args []string) map[string]string {
+	sen, err := sentinel.NewSentinelPod(ctx, args[0], sentinelContainerName, sentinelNamespace, sentinelPort, redisPort, conn, clientset, restcfg)
+	if err != nil {
+		klog.V(2).Info(err)
+		return nil
+	}
+	pod, err := sen.MasterPodName(ctx, args[1])
+	if err != nil {
+		klog.V(2).Info(err)
+		return nil
+	}
+	return map[string]string{pod: "master of " + args[1]}
+}
+
+// roleSnapshot returns replication role of every pod in args
+func roleSnapshot(ctx context.Context, args []string) map[string]string {
+	res := make(map[string]string)
+	for _, name := range args {
+		pod, err := sentinel.NewRedisPod(ctx, name, redisContainerName, sentinelNamespace, redisPort, conn, clientset, restcfg)
+		if err != nil {
+			klog.V(2).Info(err)
+			continue
+		}
+		role, err := pod.Role(ctx)
+		if err != nil {
+			klog.V(2).Info(err)
+			continue
+		}
+		res[name] = role
+	}
+	return res
+}
+
+// stsSnapshot returns uid of every pod in statefulset args[0]
+func stsSnapshot(ctx context.Context, args []string) map[string]string {
+	pods, err := common.StatefulSetPods(ctx, clientset, sentinelNamespace, args[0])
+	if err != nil {
+		klog.V(2).Info(err)
+		return nil
+	}
+	res := make(map[string]string)
+	for _, p := range pods {
+		res[p.Name] = string(p.UID)
+	}
+	return res
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2020 Will Xu <xyj.asmy@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+	"github.com/monsterxx03/kuberc/pkg/sentinel"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type completeFunc func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective)
+
+// completionCmd represents the completion command
+var completionCmd = &cobra.Command{
+	Use:   "completion <bash|zsh|fish|powershell>",
+	Short: "Generate shell completion script",
+	Long: `Generate shell completion script, eg:
+
+    source <(kubectl-sen completion bash)
+`,
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	// completion doesn't need k8s clients
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error { return nil },
+	RunE: func(cmd *cobra.Command, args []string) error {
+		switch args[0] {
+		case "bash":
+			return rootCmd.GenBashCompletion(os.Stdout)
+		case "zsh":
+			return rootCmd.GenZshCompletion(os.Stdout)
+		case "fish":
+			return rootCmd.GenFishCompletion(os.Stdout, true)
+		default:
+			return rootCmd.GenPowerShellCompletion(os.Stdout)
+		}
+	},
+}
+
+// filterCompletions returns candidates with prefix toComplete, which are not in args yet
+func filterCompletions(candidates []string, args []string, toComplete string) []string {
+	used := make(map[string]bool)
+	for _, a := range args {
+		used[a] = true
+	}
+	res := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if !used[c] && strings.HasPrefix(c, toComplete) {
+			res = append(res, c)
+		}
+	}
+	return res
+}
+
+// podNames lists names of pods in namespace with a container declaring port
+func podNames(port int) ([]string, error) {
+	if err := initClients(); err != nil {
+		return nil, err
+	}
+	pods, err := common.ListRedisPods(ctx, clientset, sentinelNamespace, port)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(pods))
+	for _, p := range pods {
+		names = append(names, p.Name)
+	}
+	return names, nil
+}
+
+// completeSentinelMaster completes sentinel pod as first arg, master names monitored by it as second arg
+func completeSentinelMaster(withMaster bool) completeFunc {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		switch {
+		case len(args) == 0:
+			names, err := podNames(sentinelPort)
+			if err != nil {
+				cobra.CompDebugln(err.Error(), true)
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return filterCompletions(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+		case len(args) == 1 && withMaster:
+			if err := initClients(); err != nil {
+				cobra.CompDebugln(err.Error(), true)
+				return nil, cobra.ShellCompDirectiveError
+			}
+			sen, err := sentinel.NewSentinelPod(ctx, args[0], sentinelContainerName, sentinelNamespace, sentinelPort, redisPort, conn, clientset, restcfg)
+			if err != nil {
+				cobra.CompDebugln(err.Error(), true)
+				return nil, cobra.ShellCompDirectiveError
+			}
+			names, err := sen.MasterNames(ctx)
+			if err != nil {
+				cobra.CompDebugln(err.Error(), true)
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return filterCompletions(names, nil, toComplete), cobra.ShellCompDirectiveNoFileComp
+		}
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeRedisPods completes redis pods for sync
+func completeRedisPods(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) >= 2 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, err := podNames(redisPort)
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveError
+	}
+	return filterCompletions(names, args, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeStatefulSets completes statefulsets in namespace for restart
+func completeStatefulSets(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	if err := initClients(); err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveError
+	}
+	r, err := clientset.AppsV1().StatefulSets(sentinelNamespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		cobra.CompDebugln(err.Error(), true)
+		return nil, cobra.ShellCompDirectiveError
+	}
+	names := make([]string, 0, len(r.Items))
+	for _, s := range r.Items {
+		names = append(names, s.Name)
+	}
+	return filterCompletions(names, nil, toComplete), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
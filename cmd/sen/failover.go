@@ -32,14 +32,16 @@ var failoverCmd = &cobra.Command{
 	Short: "Failover redis to slave pod",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		sen, err := sentinel.NewSentinelPod(args[0], sentinelContainerName, sentinelNamespace, sentinelPort, redisPort,clientset, restcfg)
+		sen, err := sentinel.NewSentinelPod(ctx, args[0], sentinelContainerName, sentinelNamespace, sentinelPort, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		return sen.Failover(args[1])
+		return sen.Failover(ctx, args[1])
 	},
 }
 
 func init() {
+	audit(failoverCmd, masterSnapshot)
+	failoverCmd.ValidArgsFunction = completeSentinelMaster(true)
 	rootCmd.AddCommand(failoverCmd)
 }
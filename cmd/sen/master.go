@@ -32,14 +32,15 @@ var masterCmd = &cobra.Command{
 	Short: "Show redis master pod info",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		sen, err := sentinel.NewSentinelPod(args[0], sentinelContainerName, sentinelNamespace, sentinelPort, redisPort,clientset, restcfg)
+		sen, err := sentinel.NewSentinelPod(ctx, args[0], sentinelContainerName, sentinelNamespace, sentinelPort, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		return sen.Master(args[1])
+		return sen.Master(ctx, args[1])
 	},
 }
 
 func init() {
+	masterCmd.ValidArgsFunction = completeSentinelMaster(true)
 	rootCmd.AddCommand(masterCmd)
 }
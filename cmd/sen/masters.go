@@ -32,14 +32,15 @@ var mastersCmd = &cobra.Command{
 	Short: "List redis masters",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		sen, err := sentinel.NewSentinelPod(args[0], sentinelContainerName, sentinelNamespace, sentinelPort, redisPort, clientset, restcfg)
+		sen, err := sentinel.NewSentinelPod(ctx, args[0], sentinelContainerName, sentinelNamespace, sentinelPort, redisPort, conn, clientset, restcfg)
 		if err != nil {
 			return err
 		}
-		return sen.Masters()
+		return sen.Masters(ctx)
 	},
 }
 
 func init() {
+	mastersCmd.ValidArgsFunction = completeSentinelMaster(false)
 	rootCmd.AddCommand(mastersCmd)
 }
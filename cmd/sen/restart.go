@@ -31,10 +31,12 @@ var restartCmd = &cobra.Command{
 	Short: "restart pods in sentinel sts one by one",
 	Args:  cobra.ExactArgs(1),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		return sentinel.Restart(args[0], sentinelNamespace, clientset, restcfg)
+		return sentinel.Restart(ctx, args[0], sentinelNamespace, clientset, restcfg)
 	},
 }
 
 func init() {
+	audit(restartCmd, stsSnapshot)
+	restartCmd.ValidArgsFunction = completeStatefulSets
 	rootCmd.AddCommand(restartCmd)
 }
@@ -22,26 +22,34 @@ THE SOFTWARE.
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/spf13/cobra"
 	"os"
+	"sync"
 
-	"github.com/mitchellh/go-homedir"
 	"github.com/monsterxx03/kuberc/pkg/common"
 	"github.com/spf13/pflag"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/klog/v2"
 )
 
-var cfgFile string
+var kubeFlags = genericclioptions.NewConfigFlags(true)
+var ctx context.Context
+var cancel context.CancelFunc
 var sentinelNamespace string
 var sentinelContainerName string
 var sentinelPort int
 var redisPort int
 var redisContainerName string
+var redisUser string
+var redisPassword string
+var redisPasswordSecret string
+var tlsOpts common.TLSOptions
+var conn *common.ConnConfig
 var restcfg *restclient.Config
 var clientset *kubernetes.Clientset
 
@@ -51,32 +59,68 @@ var rootCmd = &cobra.Command{
 	Short: "Manage redis-sentinel on k8s",
 	Long:  `Used as kubectl plugin. Get redis pods monitored by redis-sentinel, to failover, replace pods.`,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		var err error
-		if os.Getenv("KUBECONFIG") != "" {
-			cfgFile = os.Getenv("KUBECONFIG")
-		} else {
-			cfgFile, err = homedir.Expand(cfgFile)
-			if err != nil {
-				return err
-			}
+		// completion builds clients only when completing names, and never uses local port
+		if isCompletion(cmd) {
+			return nil
 		}
-		restcfg, err = clientcmd.BuildConfigFromFlags("", cfgFile)
-		if err != nil {
+		if err := initClients(); err != nil {
 			return err
 		}
-		clientset, err = kubernetes.NewForConfig(restcfg)
-		if err != nil {
-			return err
-		}
-		if err := common.CheckPort(sentinelPort); err != nil {
-			return err
-		}
-		return nil
+		return common.CheckPort(sentinelPort)
 	},
 }
 
+var clientsOnce sync.Once
+var clientsErr error
+
+// initClients builds k8s clients, context and redis connection config from flags, only once
+func initClients() error {
+	clientsOnce.Do(func() { clientsErr = buildClients() })
+	return clientsErr
+}
+
+// isCompletion is true for completion script generation and the hidden completion request commands
+func isCompletion(cmd *cobra.Command) bool {
+	switch cmd.Name() {
+	case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	return false
+}
+
+func buildClients() error {
+	var err error
+	restcfg, err = kubeFlags.ToRESTConfig()
+	if err != nil {
+		return err
+	}
+	// namespace from flag, or from current context in kubeconfig
+	sentinelNamespace, _, err = kubeFlags.ToRawKubeConfigLoader().Namespace()
+	if err != nil {
+		return err
+	}
+	clientset, err = kubernetes.NewForConfig(restcfg)
+	if err != nil {
+		return err
+	}
+	timeout, err := common.ParseTimeout(*kubeFlags.Timeout)
+	if err != nil {
+		return err
+	}
+	ctx, cancel = common.NewContext(timeout)
+	conn, err = common.NewConnConfig(ctx, redisUser, redisPassword, redisPasswordSecret, &tlsOpts, sentinelNamespace, clientset)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if cancel != nil {
+		cancel()
+	}
+	if err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
@@ -86,9 +130,22 @@ func init() {
 	rootCmd.PersistentFlags().IntVarP(&sentinelPort, "port", "p", 26379, "redis-sentinel port")
 	rootCmd.PersistentFlags().IntVarP(&redisPort, "redis-port", "", 6379, "redis port")
 	rootCmd.PersistentFlags().StringVarP(&redisContainerName, "redis-container", "", "", "redis cointainer name")
-	rootCmd.PersistentFlags().StringVarP(&sentinelNamespace, "namespace", "n", "default", "sentinel pod namespace")
 	rootCmd.PersistentFlags().StringVarP(&sentinelContainerName, "container", "c", "", "sentinel container name")
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "~/.kube/config", "kubeconfig used for kubectl, will try to load from $KUBECONFIG first")
+	kubeFlags.AddFlags(rootCmd.PersistentFlags())
+	rootCmd.PersistentFlags().StringVar(kubeFlags.KubeConfig, "config", "", "kubeconfig used for kubectl")
+	rootCmd.PersistentFlags().MarkDeprecated("config", "use --kubeconfig instead")
+	rootCmd.PersistentFlags().StringVar(&redisUser, "redis-user", "", "acl user for redis and sentinel")
+	rootCmd.PersistentFlags().StringVar(&redisPassword, "password", "", "password for redis and sentinel")
+	rootCmd.PersistentFlags().StringVar(&redisPasswordSecret, "password-secret", "", "load password from secret in namespace, eg: redis-auth/password")
+	rootCmd.PersistentFlags().BoolVar(&tlsOpts.Enabled, "tls", false, "connect with tls")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.CACert, "cacert", "", "ca cert file path in redis container, for redis-cli")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.Cert, "cert", "", "client cert file path in redis container, for redis-cli")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.Key, "key", "", "client key file path in redis container, for redis-cli")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.SNI, "sni", "", "server name indication for tls")
+	rootCmd.PersistentFlags().BoolVar(&tlsOpts.Insecure, "insecure", false, "skip tls server certificate verification")
+	rootCmd.PersistentFlags().StringVar(&tlsOpts.Secret, "tls-secret", "", "secret in namespace with ca.crt/tls.crt/tls.key, for connections through port-forward")
+	rootCmd.PersistentFlags().StringVar(&auditLog, "audit-log", common.DefaultAuditLogPath(), "append records of mutating commands to this file, empty to disable")
+	rootCmd.PersistentFlags().BoolVar(&auditEvents, "audit-events", true, "create k8s events on pods affected by mutating commands")
 	klog.InitFlags(nil)
 	pflag.CommandLine.AddGoFlag(flag.CommandLine.Lookup("v"))
 }
@@ -31,7 +31,7 @@ var syncCmd = &cobra.Command{
 	Short: "make <slave-pod> slave of <master-pod>",
 	Args:  cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
-		err := sentinel.Sync(args[0], args[1], redisContainerName, sentinelNamespace, redisPort, clientset, restcfg, true)
+		err := sentinel.Sync(ctx, args[0], args[1], redisContainerName, sentinelNamespace, redisPort, conn, clientset, restcfg, true)
 		if err != nil {
 			return err
 		}
@@ -40,5 +40,7 @@ var syncCmd = &cobra.Command{
 }
 
 func init() {
+	audit(syncCmd, roleSnapshot)
+	syncCmd.ValidArgsFunction = completeRedisPods
 	rootCmd.AddCommand(syncCmd)
 }
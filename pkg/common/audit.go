@@ -0,0 +1,197 @@
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+// AuditRecord describes a single run of a mutating command
+type AuditRecord struct {
+	Time      time.Time   `json:"time"`
+	User      string      `json:"user"`
+	Command   string      `json:"command"`
+	Args      []string    `json:"args"`
+	Namespace string      `json:"namespace"`
+	Objects   []string    `json:"objects,omitempty"`
+	Before    interface{} `json:"before,omitempty"`
+	After     interface{} `json:"after,omitempty"`
+	Result    string      `json:"result"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// SetResult fills result of command from its error
+func (r *AuditRecord) SetResult(err error) {
+	if err != nil {
+		r.Result = "failed"
+		r.Error = err.Error()
+	} else {
+		r.Result = "success"
+	}
+}
+
+// KubeUser returns user running the command: impersonated user, or user of current context in kubeconfig
+func KubeUser(flags *genericclioptions.ConfigFlags) string {
+	if flags.Impersonate != nil && *flags.Impersonate != "" {
+		return *flags.Impersonate
+	}
+	if flags.AuthInfoName != nil && *flags.AuthInfoName != "" {
+		return *flags.AuthInfoName
+	}
+	raw, err := flags.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return "unknown"
+	}
+	if c, ok := raw.Contexts[KubeContext(flags)]; ok && c.AuthInfo != "" {
+		return c.AuthInfo
+	}
+	return "unknown"
+}
+
+// KubeContext returns name of kubeconfig context in use, empty if unknown
+func KubeContext(flags *genericclioptions.ConfigFlags) string {
+	if flags.Context != nil && *flags.Context != "" {
+		return *flags.Context
+	}
+	raw, err := flags.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		return ""
+	}
+	return raw.CurrentContext
+}
+
+// auditRedactedFlags are never written into audit records
+var auditRedactedFlags = map[string]bool{"password": true, "token": true}
+
+// AuditArgs returns positional args and flags changed by user, secrets are redacted
+func AuditArgs(flags *pflag.FlagSet, args []string) []string {
+	res := append([]string{}, args...)
+	flags.Visit(func(f *pflag.Flag) {
+		v := f.Value.String()
+		if auditRedactedFlags[f.Name] {
+			v = "***"
+		}
+		res = append(res, fmt.Sprintf("--%s=%s", f.Name, v))
+	})
+	return res
+}
+
+// DefaultAuditLogPath is ~/.kuberc/audit.log, empty if home dir is unknown
+func DefaultAuditLogPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".kuberc", "audit.log")
+}
+
+// Auditor appends audit records to a local json lines file and emits them as k8s events
+type Auditor struct {
+	logPath   string
+	events    bool
+	clientset *kubernetes.Clientset
+}
+
+func NewAuditor(logPath string, events bool, clientset *kubernetes.Clientset) *Auditor {
+	return &Auditor{logPath: logPath, events: events, clientset: clientset}
+}
+
+// Record writes rec and emits an event on every object, failures are only logged
+// since the command itself has already run.
+func (a *Auditor) Record(ctx context.Context, rec *AuditRecord, objects []*corev1.ObjectReference) {
+	for _, o := range objects {
+		rec.Objects = append(rec.Objects, strings.ToLower(o.Kind)+"/"+o.Name)
+	}
+	if a.logPath != "" {
+		if err := a.writeLog(rec); err != nil {
+			klog.Warningf("failed to write audit log %s: %v", a.logPath, err)
+		}
+	}
+	if !a.events {
+		return
+	}
+	for _, o := range objects {
+		if err := a.emitEvent(ctx, rec, o); err != nil {
+			klog.Warningf("failed to create audit event on %s/%s: %v", o.Kind, o.Name, err)
+		}
+	}
+}
+
+func (a *Auditor) writeLog(rec *AuditRecord) error {
+	if err := os.MkdirAll(filepath.Dir(a.logPath), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(a.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+func (a *Auditor) emitEvent(ctx context.Context, rec *AuditRecord, obj *corev1.ObjectReference) error {
+	eventType := corev1.EventTypeNormal
+	msg := fmt.Sprintf("%s ran `%s %s`: %s", rec.User, rec.Command, strings.Join(rec.Args, " "), rec.Result)
+	if rec.Error != "" {
+		eventType = corev1.EventTypeWarning
+		msg += ", " + rec.Error
+	}
+	now := metav1.NewTime(rec.Time)
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s.%x", obj.Name, time.Now().UnixNano()),
+			Namespace: obj.Namespace,
+		},
+		InvolvedObject: *obj,
+		Reason:         auditReason(rec.Command),
+		Message:        msg,
+		Source:         corev1.EventSource{Component: "kuberc"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Type:           eventType,
+	}
+	_, err := a.clientset.CoreV1().Events(obj.Namespace).Create(ctx, event, metav1.CreateOptions{})
+	return err
+}
+
+// auditReason converts command path to event reason, eg: "rc del-node" => "RcDelNode"
+func auditReason(command string) string {
+	reason := ""
+	for _, w := range strings.FieldsFunc(command, func(r rune) bool { return r == ' ' || r == '-' }) {
+		reason += strings.ToUpper(w[:1]) + w[1:]
+	}
+	return reason
+}
+
+// PodRefs returns object references of existing pods in names
+func PodRefs(ctx context.Context, clientset *kubernetes.Clientset, namespace string, names []string) []*corev1.ObjectReference {
+	sort.Strings(names)
+	refs := make([]*corev1.ObjectReference, 0, len(names))
+	for _, name := range names {
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			klog.V(2).Infof("skip audit event on pod %s: %v", name, err)
+			continue
+		}
+		refs = append(refs, &corev1.ObjectReference{Kind: "Pod", APIVersion: "v1", Namespace: pod.Namespace, Name: pod.Name, UID: pod.UID, ResourceVersion: pod.ResourceVersion})
+	}
+	return refs
+}
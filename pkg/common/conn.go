@@ -0,0 +1,217 @@
+package common
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+
+	"github.com/go-redis/redis/v8"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// ConnConfig holds settings to connect to redis/sentinel, shared by redis-cli in pod and go-redis clients.
+type ConnConfig struct {
+	User     string
+	Password string
+	TLS      *TLSOptions
+	// tlsConfig is used by local go-redis clients
+	tlsConfig *tls.Config
+}
+
+// TLSOptions are tls settings. CACert, Cert and Key are file paths in redis container, used by redis-cli,
+// local go-redis clients (through port-forward) load certs from Secret, which should
+// contain ca.crt, tls.crt and tls.key like secrets created by cert-manager.
+type TLSOptions struct {
+	Enabled  bool
+	CACert   string
+	Cert     string
+	Key      string
+	SNI      string
+	Insecure bool
+	Secret   string
+}
+
+func (t *TLSOptions) enabled() bool {
+	return t != nil && (t.Enabled || t.CACert != "" || t.Cert != "" || t.Secret != "")
+}
+
+// CliArgs returns extra arguments for redis-cli, password is not included,
+// it's passed through CliEnv so it won't show up in process arguments. Values are quoted for sh -c.
+func (c *ConnConfig) CliArgs() string {
+	if c == nil {
+		return ""
+	}
+	args := make([]string, 0)
+	if c.User != "" {
+		args = append(args, "--user "+ShellQuote(c.User))
+	}
+	if c.TLS.enabled() {
+		args = append(args, "--tls")
+		if c.TLS.CACert != "" {
+			args = append(args, "--cacert "+ShellQuote(c.TLS.CACert))
+		}
+		if c.TLS.Cert != "" {
+			args = append(args, "--cert "+ShellQuote(c.TLS.Cert))
+		}
+		if c.TLS.Key != "" {
+			args = append(args, "--key "+ShellQuote(c.TLS.Key))
+		}
+		if c.TLS.SNI != "" {
+			args = append(args, "--sni "+ShellQuote(c.TLS.SNI))
+		}
+		if c.TLS.Insecure {
+			args = append(args, "--insecure")
+		}
+	}
+	return strings.Join(args, " ")
+}
+
+// CliEnv returns environment variables for redis-cli
+func (c *ConnConfig) CliEnv() map[string]string {
+	env := make(map[string]string)
+	if c != nil && c.Password != "" {
+		env["REDISCLI_AUTH"] = c.Password
+	}
+	return env
+}
+
+// ApplyTo sets credentials and tls config on go-redis options
+func (c *ConnConfig) ApplyTo(opt *redis.Options) *redis.Options {
+	if c != nil {
+		opt.Username = c.User
+		opt.Password = c.Password
+		if c.tlsConfig != nil {
+			opt.TLSConfig = c.tlsConfig.Clone()
+		}
+	}
+	return opt
+}
+
+// Dial connects to redis at addr, with tls and auth applied, for commands not supported by go-redis (eg: monitor)
+func (c *ConnConfig) Dial(ctx context.Context, addr string) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return conn, nil
+	}
+	if c.tlsConfig != nil {
+		cfg := c.tlsConfig.Clone()
+		if cfg.ServerName == "" {
+			cfg.ServerName, _, _ = net.SplitHostPort(addr)
+		}
+		conn = tls.Client(conn, cfg)
+	}
+	if c.Password != "" {
+		args := []string{"AUTH", c.Password}
+		if c.User != "" {
+			args = []string{"AUTH", c.User, c.Password}
+		}
+		if err := WriteCommand(conn, args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		line, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		if strings.HasPrefix(line, "-") {
+			conn.Close()
+			return nil, errors.New(strings.TrimSpace(line[1:]))
+		}
+	}
+	return conn, nil
+}
+
+// WriteCommand writes command in redis protocol
+func WriteCommand(w io.Writer, args ...string) error {
+	buf := new(bytes.Buffer)
+	fmt.Fprintf(buf, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(buf, "$%d\r\n%s\r\n", len(a), a)
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// GetSecretValue reads value from secret, ref is in format <secret-name>/<key>
+func GetSecretValue(ctx context.Context, clientset *kubernetes.Clientset, namespace, ref string) (string, error) {
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("wrong secret ref %s, should be <name>/<key>", ref)
+	}
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, parts[0], metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	val, ok := secret.Data[parts[1]]
+	if !ok {
+		return "", fmt.Errorf("can't find key %s in secret %s", parts[1], parts[0])
+	}
+	return strings.TrimRight(string(val), "\r\n"), nil
+}
+
+// NewConnConfig builds ConnConfig from flags, password is loaded from passwordSecret if it's set.
+func NewConnConfig(ctx context.Context, user, password, passwordSecret string, tlsOpts *TLSOptions, namespace string, clientset *kubernetes.Clientset) (*ConnConfig, error) {
+	if password != "" && passwordSecret != "" {
+		return nil, fmt.Errorf("password and password-secret can't be passed at sametime")
+	}
+	if passwordSecret != "" {
+		var err error
+		password, err = GetSecretValue(ctx, clientset, namespace, passwordSecret)
+		if err != nil {
+			return nil, err
+		}
+	}
+	c := &ConnConfig{User: user, Password: password, TLS: tlsOpts}
+	if tlsOpts.enabled() {
+		cfg, err := newTLSConfig(ctx, tlsOpts, namespace, clientset)
+		if err != nil {
+			return nil, err
+		}
+		c.tlsConfig = cfg
+	}
+	return c, nil
+}
+
+func newTLSConfig(ctx context.Context, opts *TLSOptions, namespace string, clientset *kubernetes.Clientset) (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: opts.SNI, InsecureSkipVerify: opts.Insecure}
+	if opts.Secret == "" {
+		return cfg, nil
+	}
+	secret, err := clientset.CoreV1().Secrets(namespace).Get(ctx, opts.Secret, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	if ca, ok := secret.Data["ca.crt"]; ok {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("failed to parse ca.crt in secret %s", opts.Secret)
+		}
+		cfg.RootCAs = pool
+	}
+	crt, hasCrt := secret.Data["tls.crt"]
+	key, hasKey := secret.Data["tls.key"]
+	if hasCrt != hasKey {
+		return nil, errors.New("tls.crt and tls.key should be both set in secret " + opts.Secret)
+	}
+	if hasCrt {
+		cert, err := tls.X509KeyPair(crt, key)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
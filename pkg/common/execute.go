@@ -2,6 +2,7 @@ package common
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	corev1 "k8s.io/api/core/v1"
@@ -11,27 +12,79 @@ import (
 	"k8s.io/client-go/tools/remotecommand"
 	"k8s.io/klog/v2"
 	"os"
+	"sort"
+	"strings"
+	"time"
 )
 
 type ExecTarget struct {
 	Pod       *corev1.Pod
 	Container string
+	// Env is passed to cmd through stdin, so values won't show up in process arguments or logs
+	Env map[string]string
 }
 
-func Execute(clientset *kubernetes.Clientset, restcfg *restclient.Config, target *ExecTarget, cmd string, toStdout, toStdin bool) (string, error) {
+// killTimeout bounds killing remote command after ctx is done
+const killTimeout = 5 * time.Second
+
+// Execute runs cmd by sh -c in pod. When ctx is done before cmd finishes, cmd and its children are killed in pod,
+// since closing the stream doesn't stop them.
+func Execute(ctx context.Context, clientset *kubernetes.Clientset, restcfg *restclient.Config, target *ExecTarget, cmd string, toStdout, toStdin bool) (string, error) {
+	pidFile := fmt.Sprintf("/tmp/kuberc-%d-%d.pid", os.Getpid(), time.Now().UnixNano())
+	out, err := execute(ctx, clientset, restcfg, target, cmd, toStdout, toStdin, pidFile)
+	if err != nil && ctx.Err() != nil {
+		killRemote(clientset, restcfg, target, pidFile)
+	}
+	return out, err
+}
+
+// killRemote sends SIGTERM to process group of shell saved in pidFile, or to the shell alone if it isn't a group leader.
+// It's best effort, pod may have no writable /tmp or be gone already.
+func killRemote(clientset *kubernetes.Clientset, restcfg *restclient.Config, target *ExecTarget, pidFile string) {
+	ctx, cancel := context.WithTimeout(context.Background(), killTimeout)
+	defer cancel()
+	kill := fmt.Sprintf("pid=$(cat %s) && { kill -TERM -$pid 2>/dev/null || kill -TERM $pid; }", pidFile)
+	if _, err := execute(ctx, clientset, restcfg, &ExecTarget{Pod: target.Pod, Container: target.Container}, kill, false, false, ""); err != nil {
+		klog.V(2).Infof("failed to kill command in %s: %v", target.Pod.Name, err)
+	}
+}
+
+// execute runs cmd in pod, shell pid is saved into pidFile in pod if it's not empty and removed on exit
+func execute(ctx context.Context, clientset *kubernetes.Clientset, restcfg *restclient.Config, target *ExecTarget, cmd string, toStdout, toStdin bool, pidFile string) (string, error) {
 	req := clientset.CoreV1().RESTClient().Post().Resource("pods").Name(target.Pod.Name).Namespace(target.Pod.Namespace).SubResource("exec")
 	klog.V(2).Info("execute in %s: %s", target.Pod.Name, cmd)
 	containerName := target.Pod.Spec.Containers[0].Name
 	if target.Container != "" {
 		containerName = target.Container
 	}
+	var envIn io.Reader
+	if len(target.Env) > 0 {
+		keys := make([]string, 0, len(target.Env))
+		values := new(bytes.Buffer)
+		for k := range target.Env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		reads := make([]string, 0, len(keys))
+		for _, k := range keys {
+			reads = append(reads, "read -r "+k)
+			values.WriteString(target.Env[k] + "\n")
+		}
+		cmd = fmt.Sprintf("%s && export %s && %s", strings.Join(reads, " && "), strings.Join(keys, " "), cmd)
+		envIn = values
+	}
+	if pidFile != "" {
+		// pid file is optional, pod may have read only /tmp
+		cmd = fmt.Sprintf("{ echo $$ > %s; } 2>/dev/null; trap 'rm -f %s' EXIT; %s", pidFile, pidFile, cmd)
+	}
 	req.VersionedParams(&corev1.PodExecOptions{
 		Container: containerName,
 		Command:   []string{"sh", "-c", cmd},
-		Stdin:     toStdin,
+		Stdin:     toStdin || envIn != nil,
 		Stderr:    true,
 		Stdout:    true,
-		TTY:       true,
+		// tty echoes stdin back, can't be used when passing env
+		TTY: envIn == nil,
 	}, scheme.ParameterCodec)
 	exec, err := remotecommand.NewSPDYExecutor(restcfg, "POST", req.URL())
 	if err != nil {
@@ -48,15 +101,34 @@ func Execute(clientset *kubernetes.Clientset, restcfg *restclient.Config, target
 	if toStdin {
 		stdin = os.Stdin
 	}
+	if envIn != nil {
+		if stdin != nil {
+			stdin = io.MultiReader(envIn, stdin)
+		} else {
+			stdin = envIn
+		}
+	}
 	opt := remotecommand.StreamOptions{
 		Stdin:  stdin,
 		Stdout: stdout,
 		Stderr: os.Stderr,
 	}
-	err = exec.Stream(opt)
+	// Stream can't be cancelled in this client-go version, stop waiting for it when ctx is done
+	done := make(chan error, 1)
+	go func() { done <- exec.Stream(opt) }()
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
 	if err != nil {
 		fmt.Println(buf.String())
 		return "", err
 	}
 	return buf.String(), nil
 }
+
+// ShellQuote quotes s as a single argument of sh -c
+func ShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -3,6 +3,7 @@ package common
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -10,8 +11,8 @@ import (
 	restclient "k8s.io/client-go/rest"
 )
 
-func GetPod(podName, containerName, namespace string, clientset *kubernetes.Clientset, restcfg *restclient.Config) (*corev1.Pod, error) {
-	pod, err := clientset.CoreV1().Pods(namespace).Get(context.Background(), podName, metav1.GetOptions{})
+func GetPod(ctx context.Context, podName, containerName, namespace string, clientset *kubernetes.Clientset, restcfg *restclient.Config) (*corev1.Pod, error) {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -28,3 +29,72 @@ func GetPod(podName, containerName, namespace string, clientset *kubernetes.Clie
 	}
 	return pod, nil
 }
+
+// PodIndex maps pod ip and statefulset dns names (<hostname>.<subdomain>[.<namespace>[.svc]]) to pod
+type PodIndex map[string]*corev1.Pod
+
+func NewPodIndex(pods []corev1.Pod) PodIndex {
+	idx := make(PodIndex)
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Status.PodIP != "" {
+			idx[pod.Status.PodIP] = pod
+		}
+		for _, ip := range pod.Status.PodIPs {
+			idx[ip.IP] = pod
+		}
+		idx[pod.Name] = pod
+		if pod.Spec.Hostname != "" && pod.Spec.Subdomain != "" {
+			name := pod.Spec.Hostname + "." + pod.Spec.Subdomain
+			idx[name] = pod
+			idx[name+"."+pod.Namespace] = pod
+			idx[name+"."+pod.Namespace+".svc"] = pod
+		}
+	}
+	return idx
+}
+
+// Find returns the pod matching any of addrs, addrs can be ip or hostname,
+// cluster domain suffix of hostname is ignored.
+func (idx PodIndex) Find(addrs ...string) (*corev1.Pod, bool) {
+	for _, addr := range addrs {
+		addr = strings.TrimSuffix(addr, ".")
+		if addr == "" || addr == "?" {
+			continue
+		}
+		if pod, ok := idx[addr]; ok {
+			return pod, true
+		}
+		if i := strings.Index(addr, ".svc."); i >= 0 {
+			if pod, ok := idx[addr[:i+4]]; ok {
+				return pod, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ListRedisPods lists running pods in namespace with a container declaring port,
+// or with a redis image when no container port is declared.
+func ListRedisPods(ctx context.Context, clientset *kubernetes.Clientset, namespace string, port int) ([]corev1.Pod, error) {
+	r, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{FieldSelector: "status.phase=Running"})
+	if err != nil {
+		return nil, err
+	}
+	pods := make([]corev1.Pod, 0)
+	for _, p := range r.Items {
+		for _, c := range p.Spec.Containers {
+			matched := len(c.Ports) == 0 && strings.Contains(c.Image, "redis")
+			for _, cp := range c.Ports {
+				if int(cp.ContainerPort) == port {
+					matched = true
+				}
+			}
+			if matched {
+				pods = append(pods, p)
+				break
+			}
+		}
+	}
+	return pods, nil
+}
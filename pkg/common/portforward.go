@@ -1,13 +1,13 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"net/http"
 	"os"
-	"os/signal"
-	"syscall"
+	"sync"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
@@ -29,6 +29,7 @@ type PortForwarder struct {
 	StopCh     chan struct{}
 	ReadyCh    chan struct{}
 	Started    bool
+	stopOnce   sync.Once
 }
 
 func NewPortForwarder(clientset *kubernetes.Clientset, restcfg *restclient.Config, pod *corev1.Pod, podPort, localPort int) *PortForwarder {
@@ -41,11 +42,14 @@ func (p *PortForwarder) Stop() {
 	if !p.Started {
 		return
 	}
-	klog.V(2).Infof("Stop port forwarding for %s:%d->%d\n", p.Pod.Name, p.PodPort, p.LocalPort)
-	close(p.StopCh)
+	p.stopOnce.Do(func() {
+		klog.V(2).Infof("Stop port forwarding for %s:%d->%d\n", p.Pod.Name, p.PodPort, p.LocalPort)
+		close(p.StopCh)
+	})
 }
 
-func (p *PortForwarder) Start() error {
+// Start port forwarding, it's stopped when ctx is done
+func (p *PortForwarder) Start(ctx context.Context) error {
 	if p.Started {
 		klog.V(2).Info("port forwarding already started")
 		return nil
@@ -60,18 +64,33 @@ func (p *PortForwarder) Start() error {
 	if err != nil {
 		return err
 	}
-	sigs := make(chan os.Signal, 1)
-	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-sigs
-		p.Stop()
-	}()
-	go func() { fw.ForwardPorts() }()
+	errCh := make(chan error, 1)
+	go func() { errCh <- fw.ForwardPorts() }()
 	select {
 	case <-p.ReadyCh:
-		break
+		p.Started = true
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		p.stopOnce.Do(func() { close(p.StopCh) })
+		return ctx.Err()
 	}
-	p.Started = true
+	// local port 0 means a random port
+	if p.LocalPort == 0 {
+		ports, err := fw.GetPorts()
+		if err != nil {
+			p.Stop()
+			return err
+		}
+		p.LocalPort = int(ports[0].Local)
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+			p.Stop()
+		case <-errCh:
+		}
+	}()
 	klog.V(2).Infof("Port forwarding for %s:%d->%d is ready\n", p.Pod.Name, p.PodPort, p.LocalPort)
 	return nil
 }
@@ -0,0 +1,106 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+func PodIsReady(pod corev1.Pod) bool {
+	if pod.Status.Phase != "Running" {
+		return false
+	}
+	for _, s := range pod.Status.ContainerStatuses {
+		if s.Started == nil || !*s.Started || !s.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// StatefulSetPodName returns name of pod with ordinal in statefulset
+func StatefulSetPodName(stsName string, ordinal int) string {
+	return fmt.Sprintf("%s-%d", stsName, ordinal)
+}
+
+// StatefulSetPods lists pods selected by statefulset
+func StatefulSetPods(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string) ([]corev1.Pod, error) {
+	sts, err := clientset.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	selector, err := metav1.LabelSelectorAsSelector(sts.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	r, err := clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	return r.Items, nil
+}
+
+// ScaleStatefulSet updates replicas of statefulset through scale subresource
+func ScaleStatefulSet(ctx context.Context, clientset *kubernetes.Clientset, namespace, name string, replicas int32) error {
+	scale, err := clientset.AppsV1().StatefulSets(namespace).GetScale(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	scale.Spec.Replicas = replicas
+	_, err = clientset.AppsV1().StatefulSets(namespace).UpdateScale(ctx, name, scale, metav1.UpdateOptions{})
+	return err
+}
+
+// WaitPodsReady polls until all pods are ready, or timeout
+func WaitPodsReady(ctx context.Context, clientset *kubernetes.Clientset, namespace string, names []string, timeout time.Duration) error {
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := wait.PollImmediateUntil(5*time.Second, func() (bool, error) {
+		for _, name := range names {
+			pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				// pod is not created yet
+				return false, nil
+			}
+			if err != nil {
+				return false, err
+			}
+			if !PodIsReady(*pod) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}, pollCtx.Done())
+	if err != nil {
+		return fmt.Errorf("pods %v are not ready in %s: %v", names, timeout, err)
+	}
+	return nil
+}
+
+// WaitPodsDeleted polls until all pods are gone, or timeout
+func WaitPodsDeleted(ctx context.Context, clientset *kubernetes.Clientset, namespace string, names []string, timeout time.Duration) error {
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := wait.PollImmediateUntil(5*time.Second, func() (bool, error) {
+		for _, name := range names {
+			_, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+			if err == nil {
+				return false, nil
+			}
+			if !apierrors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		return true, nil
+	}, pollCtx.Done())
+	if err != nil {
+		return fmt.Errorf("pods %v are not deleted in %s: %v", names, timeout, err)
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	goredis "github.com/go-redis/redis/v8"
+)
+
+const (
+	BalanceBySlots  = "slots"
+	BalanceByKeys   = "keys"
+	BalanceByMemory = "memory"
+)
+
+// measureChunk bounds slots measured in a single pipeline
+const measureChunk = 1024
+
+// MeasureSlots returns weight of every slot owned by masters: 1 by slots, keys count, or estimated
+// bytes (average MEMORY USAGE of up to sample keys of slot multiplied by its keys count)
+func (m *SlotMigrator) MeasureSlots(ctx context.Context, by string, sample int) (map[int]int64, error) {
+	if by != BalanceBySlots && by != BalanceByKeys && by != BalanceByMemory {
+		return nil, fmt.Errorf("unknown balance measure %s, should be slots, keys or memory", by)
+	}
+	if by == BalanceByMemory && sample <= 0 {
+		return nil, fmt.Errorf("memory sample should > 0")
+	}
+	weights := make(map[int]int64)
+	for _, n := range m.masters {
+		slots := make([]int, 0, n.SlotsCount())
+		for _, s := range n.Slots {
+			for i := s.Start; i <= s.End; i++ {
+				slots = append(slots, i)
+			}
+		}
+		if len(slots) == 0 {
+			continue
+		}
+		if by == BalanceBySlots {
+			for _, s := range slots {
+				weights[s] = 1
+			}
+			continue
+		}
+		c, err := m.client(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		for start := 0; start < len(slots); start += measureChunk {
+			end := start + measureChunk
+			if end > len(slots) {
+				end = len(slots)
+			}
+			if err := measureChunkSlots(ctx, c, slots[start:end], by, sample, weights); err != nil {
+				return nil, fmt.Errorf("failed to measure slots of %s: %v", n.Pod.Name, err)
+			}
+		}
+	}
+	return weights, nil
+}
+
+func measureChunkSlots(ctx context.Context, c *goredis.Client, slots []int, by string, sample int, weights map[int]int64) error {
+	counts := make([]*goredis.IntCmd, len(slots))
+	pipe := c.Pipeline()
+	for i, s := range slots {
+		counts[i] = pipe.ClusterCountKeysInSlot(ctx, s)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	nonEmpty := make([]int, 0)
+	for i, s := range slots {
+		weights[s] = counts[i].Val()
+		if counts[i].Val() > 0 {
+			nonEmpty = append(nonEmpty, i)
+		}
+	}
+	if by == BalanceByKeys || len(nonEmpty) == 0 {
+		return nil
+	}
+
+	keys := make([]*goredis.StringSliceCmd, len(nonEmpty))
+	pipe = c.Pipeline()
+	for j, i := range nonEmpty {
+		keys[j] = pipe.ClusterGetKeysInSlot(ctx, slots[i], sample)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+	usages := make([][]*goredis.IntCmd, len(nonEmpty))
+	pipe = c.Pipeline()
+	for j := range nonEmpty {
+		for _, k := range keys[j].Val() {
+			usages[j] = append(usages[j], pipe.MemoryUsage(ctx, k))
+		}
+	}
+	// keys expired or deleted between commands reply nil, they are skipped
+	if _, err := pipe.Exec(ctx); err != nil && err != goredis.Nil {
+		return err
+	}
+	for j, i := range nonEmpty {
+		var total, n int64
+		for _, u := range usages[j] {
+			if u.Err() == nil {
+				total += u.Val()
+				n++
+			}
+		}
+		if n > 0 {
+			weights[slots[i]] = total / n * counts[i].Val()
+		}
+	}
+	return nil
+}
+
+// BalancePlan moves slots between masters to even out their weights
+type BalancePlan struct {
+	Moves []*SlotMove
+	// Before and After are total weight of masters by node id
+	Before map[string]int64
+	After  map[string]int64
+}
+
+// PlanBalance computes slot moves making weight of every master close to its share of total,
+// shares are proportional to nodeWeights (node id -> weight, default 1). Masters without slots
+// only receive slots if useEmptyMasters. Masters stop moving once off their share by less than
+// threshold percentage, every slot moves at most once.
+func PlanBalance(masters []*RedisNode, weights map[int]int64, nodeWeights map[string]int, useEmptyMasters bool, threshold int) (*BalancePlan, error) {
+	if threshold <= 0 {
+		return nil, fmt.Errorf("threshold should > 0")
+	}
+	type member struct {
+		node  *RedisNode
+		share int
+		load  int64
+		// slots sorted by weight asc, moved slots are removed
+		slots []int
+	}
+	members := make([]*member, 0, len(masters))
+	plan := &BalancePlan{Before: make(map[string]int64), After: make(map[string]int64)}
+	var total int64
+	totalShare := 0
+	for _, n := range masters {
+		share := 1
+		if w, ok := nodeWeights[n.ID]; ok {
+			share = w
+		}
+		if n.SlotsCount() == 0 && !useEmptyMasters {
+			continue
+		}
+		mb := &member{node: n, share: share}
+		for _, s := range n.Slots {
+			for i := s.Start; i <= s.End; i++ {
+				mb.slots = append(mb.slots, i)
+				mb.load += weights[i]
+			}
+		}
+		sort.SliceStable(mb.slots, func(i, j int) bool { return weights[mb.slots[i]] < weights[mb.slots[j]] })
+		plan.Before[n.ID] = mb.load
+		total += mb.load
+		totalShare += share
+		members = append(members, mb)
+	}
+	if totalShare == 0 {
+		return nil, fmt.Errorf("at least one master should have weight > 0")
+	}
+	excess := func(mb *member) int64 {
+		return mb.load - total*int64(mb.share)/int64(totalShare)
+	}
+	tolerance := total / int64(len(members)) * int64(threshold) / 100
+	receivers := make([]*member, 0, len(members))
+	for _, mb := range members {
+		if mb.share > 0 {
+			receivers = append(receivers, mb)
+		}
+	}
+
+	for len(plan.Moves) < SlotsNum {
+		sort.Slice(members, func(i, j int) bool { return excess(members[i]) > excess(members[j]) })
+		sort.Slice(receivers, func(i, j int) bool { return excess(receivers[i]) < excess(receivers[j]) })
+		src, dst := members[0], receivers[0]
+		es, ed := excess(src), excess(dst)
+		if es <= tolerance && -ed <= tolerance {
+			break
+		}
+		// heaviest slot not overshooting either side, else the lightest one still narrowing the gap
+		limit := es
+		if -ed < limit {
+			limit = -ed
+		}
+		idx := sort.Search(len(src.slots), func(i int) bool { return weights[src.slots[i]] > limit }) - 1
+		if idx < 0 || weights[src.slots[idx]] == 0 {
+			idx = sort.Search(len(src.slots), func(i int) bool { return weights[src.slots[i]] > 0 })
+			if idx == len(src.slots) || weights[src.slots[idx]] >= es-ed {
+				break
+			}
+		}
+		slot := src.slots[idx]
+		src.slots = append(src.slots[:idx], src.slots[idx+1:]...)
+		src.load -= weights[slot]
+		dst.load += weights[slot]
+		plan.Moves = append(plan.Moves, &SlotMove{Slot: slot, Source: src.node, Target: dst.node, Weight: weights[slot]})
+	}
+	// masters with weight 0 are drained, including their empty slots
+	for _, src := range members {
+		if src.share > 0 {
+			continue
+		}
+		for _, slot := range src.slots {
+			sort.Slice(receivers, func(i, j int) bool { return excess(receivers[i]) < excess(receivers[j]) })
+			dst := receivers[0]
+			src.load -= weights[slot]
+			dst.load += weights[slot]
+			plan.Moves = append(plan.Moves, &SlotMove{Slot: slot, Source: src.node, Target: dst.node, Weight: weights[slot]})
+		}
+		src.slots = nil
+	}
+	for _, mb := range members {
+		plan.After[mb.node.ID] = mb.load
+	}
+	return plan, nil
+}
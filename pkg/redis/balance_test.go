@@ -0,0 +1,233 @@
+package redis
+
+import (
+	"testing"
+)
+
+func masterNode(id string, slots ...SlotRange) *RedisNode {
+	return &RedisNode{ID: id, Flags: []string{"master"}, Slots: slots}
+}
+
+// uniformWeights gives every slot weight w
+func uniformWeights(w int64) map[int]int64 {
+	weights := make(map[int]int64)
+	for i := 0; i < SlotsNum; i++ {
+		weights[i] = w
+	}
+	return weights
+}
+
+// checkPlan verifies invariants every plan must keep
+func checkPlan(t *testing.T, masters []*RedisNode, weights map[int]int64, plan *BalancePlan) {
+	t.Helper()
+	owner := make(map[int]string)
+	for _, n := range masters {
+		for _, s := range n.Slots {
+			for i := s.Start; i <= s.End; i++ {
+				owner[i] = n.ID
+			}
+		}
+	}
+	moved := make(map[int]bool)
+	after := make(map[string]int64)
+	for id, v := range plan.Before {
+		after[id] = v
+	}
+	for _, mv := range plan.Moves {
+		if moved[mv.Slot] {
+			t.Errorf("slot %d moves more than once", mv.Slot)
+		}
+		moved[mv.Slot] = true
+		if owner[mv.Slot] != mv.Source.ID {
+			t.Errorf("slot %d moves from %s, but is owned by %s", mv.Slot, mv.Source.ID, owner[mv.Slot])
+		}
+		if mv.Source == mv.Target {
+			t.Errorf("slot %d moves to its owner %s", mv.Slot, mv.Source.ID)
+		}
+		if mv.Weight != weights[mv.Slot] {
+			t.Errorf("slot %d weight %d, expects %d", mv.Slot, mv.Weight, weights[mv.Slot])
+		}
+		after[mv.Source.ID] -= mv.Weight
+		after[mv.Target.ID] += mv.Weight
+	}
+	for id, v := range after {
+		if plan.After[id] != v {
+			t.Errorf("after of %s is %d, moves give %d", id, plan.After[id], v)
+		}
+	}
+}
+
+func TestPlanBalance(t *testing.T) {
+	skewed := uniformWeights(1)
+	for i := 0; i < 100; i++ {
+		skewed[i] = 1000
+	}
+	oneHeavy := uniformWeights(0)
+	oneHeavy[0] = 1000000
+	oneHeavy[10000] = 1
+
+	tests := []struct {
+		name            string
+		masters         []*RedisNode
+		weights         map[int]int64
+		nodeWeights     map[string]int
+		useEmptyMasters bool
+		threshold       int
+		// maxMoves bounds moves count, -1 for no bound
+		maxMoves int
+		// within is max percentage of average every receiving master may be off its share
+		within int
+		// empty are masters which must have no slot after plan
+		empty []string
+		// untouched are masters which must not receive or lose slots
+		untouched []string
+	}{
+		{
+			name:      "balanced cluster has nothing to move",
+			masters:   []*RedisNode{masterNode("a", SlotRange{0, 5460}), masterNode("b", SlotRange{5461, 10922}), masterNode("c", SlotRange{10923, 16383})},
+			weights:   uniformWeights(1),
+			threshold: 2,
+			maxMoves:  0,
+			within:    2,
+		},
+		{
+			name:      "difference under threshold is tolerated",
+			masters:   []*RedisNode{masterNode("a", SlotRange{0, 8291}), masterNode("b", SlotRange{8292, 16383})},
+			weights:   uniformWeights(1),
+			threshold: 2,
+			maxMoves:  0,
+			within:    2,
+		},
+		{
+			name:      "difference over threshold is evened out",
+			masters:   []*RedisNode{masterNode("a", SlotRange{0, 8291}), masterNode("b", SlotRange{8292, 16383})},
+			weights:   uniformWeights(1),
+			threshold: 1,
+			maxMoves:  -1,
+			within:    1,
+		},
+		{
+			name:      "heavy slots are balanced by weight not count",
+			masters:   []*RedisNode{masterNode("a", SlotRange{0, 8191}), masterNode("b", SlotRange{8192, 16383})},
+			weights:   skewed,
+			threshold: 2,
+			maxMoves:  -1,
+			within:    2,
+		},
+		{
+			name:      "empty master is ignored without use empty masters",
+			masters:   []*RedisNode{masterNode("a", SlotRange{0, 8191}), masterNode("b", SlotRange{8192, 16383}), masterNode("c")},
+			weights:   uniformWeights(1),
+			threshold: 2,
+			maxMoves:  0,
+			untouched: []string{"c"},
+		},
+		{
+			name:            "empty master receives slots with use empty masters",
+			masters:         []*RedisNode{masterNode("a", SlotRange{0, 8191}), masterNode("b", SlotRange{8192, 16383}), masterNode("c")},
+			weights:         uniformWeights(1),
+			useEmptyMasters: true,
+			threshold:       2,
+			maxMoves:        -1,
+			within:          2,
+		},
+		{
+			name:        "weight 0 master is drained including empty slots",
+			masters:     []*RedisNode{masterNode("a", SlotRange{0, 5460}), masterNode("b", SlotRange{5461, 10922}), masterNode("c", SlotRange{10923, 16383})},
+			weights:     skewed,
+			nodeWeights: map[string]int{"a": 0},
+			threshold:   2,
+			maxMoves:    -1,
+			empty:       []string{"a"},
+		},
+		{
+			name:      "unsplittable heavy slot terminates",
+			masters:   []*RedisNode{masterNode("a", SlotRange{0, 8191}), masterNode("b", SlotRange{8192, 16383})},
+			weights:   oneHeavy,
+			threshold: 2,
+			maxMoves:  0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := PlanBalance(tt.masters, tt.weights, tt.nodeWeights, tt.useEmptyMasters, tt.threshold)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkPlan(t, tt.masters, tt.weights, plan)
+			if tt.maxMoves >= 0 && len(plan.Moves) > tt.maxMoves {
+				t.Errorf("%d moves, expects at most %d", len(plan.Moves), tt.maxMoves)
+			}
+			if tt.within > 0 {
+				var total int64
+				shares := 0
+				for _, n := range tt.masters {
+					if _, ok := plan.After[n.ID]; ok {
+						total += plan.After[n.ID]
+						shares++
+					}
+				}
+				avg := total / int64(shares)
+				for id, v := range plan.After {
+					diff := v - avg
+					if diff < 0 {
+						diff = -diff
+					}
+					// one slot of overshoot is allowed besides threshold
+					if diff*100 > avg*int64(tt.within)+100*maxWeight(tt.weights) {
+						t.Errorf("%s has %d after plan, average is %d", id, v, avg)
+					}
+				}
+			}
+			for _, id := range tt.empty {
+				owned := int64(0)
+				for _, n := range tt.masters {
+					if n.ID == id {
+						owned = int64(n.SlotsCount())
+					}
+				}
+				for _, mv := range plan.Moves {
+					if mv.Target.ID == id {
+						t.Errorf("slot %d moves to drained %s", mv.Slot, id)
+					}
+					if mv.Source.ID == id {
+						owned--
+					}
+				}
+				if owned != 0 {
+					t.Errorf("%s still owns %d slots", id, owned)
+				}
+			}
+			for _, id := range tt.untouched {
+				for _, mv := range plan.Moves {
+					if mv.Source.ID == id || mv.Target.ID == id {
+						t.Errorf("%s is touched by slot %d", id, mv.Slot)
+					}
+				}
+				if _, ok := plan.After[id]; ok {
+					t.Errorf("%s is in plan", id)
+				}
+			}
+		})
+	}
+}
+
+func maxWeight(weights map[int]int64) int64 {
+	var max int64
+	for _, w := range weights {
+		if w > max {
+			max = w
+		}
+	}
+	return max
+}
+
+func TestPlanBalanceErrors(t *testing.T) {
+	masters := []*RedisNode{masterNode("a", SlotRange{0, 8191}), masterNode("b", SlotRange{8192, 16383})}
+	if _, err := PlanBalance(masters, uniformWeights(1), nil, false, 0); err == nil {
+		t.Error("threshold 0 should fail")
+	}
+	if _, err := PlanBalance(masters, uniformWeights(1), map[string]int{"a": 0, "b": 0}, false, 2); err == nil {
+		t.Error("all weights 0 should fail")
+	}
+}
@@ -0,0 +1,213 @@
+package redis
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// CheckpointMove is a slot move saved by node id, pods may be rescheduled between runs
+type CheckpointMove struct {
+	Slot   int    `json:"slot"`
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Weight int64  `json:"weight"`
+	Done   bool   `json:"-"`
+}
+
+// MigrationCheckpoint is a slot migration plan saved in a local json lines file, the first line
+// is the plan, every finished slot appends a line, so saving progress is cheap even for thousands of moves.
+type MigrationCheckpoint struct {
+	path string
+	// index is position of slot in Moves
+	index   map[int]int
+	Created time.Time        `json:"created"`
+	By      string           `json:"by"`
+	Moves   []CheckpointMove `json:"moves"`
+}
+
+// NewCheckpoint saves moves into a new checkpoint file at path
+func NewCheckpoint(path, by string, moves []*SlotMove) (*MigrationCheckpoint, error) {
+	cp := &MigrationCheckpoint{path: path, Created: time.Now(), By: by, Moves: make([]CheckpointMove, 0, len(moves))}
+	for _, mv := range moves {
+		cp.Moves = append(cp.Moves, CheckpointMove{Slot: mv.Slot, Source: mv.Source.ID, Target: mv.Target.ID, Weight: mv.Weight})
+	}
+	cp.buildIndex()
+	return cp, cp.Save()
+}
+
+func (c *MigrationCheckpoint) buildIndex() {
+	c.index = make(map[int]int, len(c.Moves))
+	for i, mv := range c.Moves {
+		c.index[mv.Slot] = i
+	}
+}
+
+// checkpointDone is a line appended to checkpoint file when a slot is migrated
+type checkpointDone struct {
+	Done int `json:"done"`
+}
+
+// LoadCheckpoint reads checkpoint file at path, returns nil if it doesn't exist.
+// A partial last line is always ignored, repair also truncates it from file before appending.
+func LoadCheckpoint(path string, repair bool) (*MigrationCheckpoint, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// drop partial line written when interrupted, its slot is checked against cluster in Resolve
+	if end := bytes.LastIndexByte(data, '\n') + 1; end < len(data) {
+		data = data[:end]
+		if repair {
+			if err := os.Truncate(path, int64(end)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	cp := &MigrationCheckpoint{path: path}
+	if err := json.Unmarshal([]byte(lines[0]), cp); err != nil {
+		return nil, fmt.Errorf("wrong checkpoint file %s: %v", path, err)
+	}
+	cp.buildIndex()
+	for i, line := range lines[1:] {
+		var d checkpointDone
+		if err := json.Unmarshal([]byte(line), &d); err != nil {
+			return nil, fmt.Errorf("wrong line %d in checkpoint file %s: %v", i+2, path, err)
+		}
+		if j, ok := cp.index[d.Done]; ok {
+			cp.Moves[j].Done = true
+		}
+	}
+	return cp, nil
+}
+
+// Save writes checkpoint to a temp file then renames it, so a crash never leaves a partial plan
+func (c *MigrationCheckpoint) Save() error {
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.path)
+}
+
+// Remove deletes checkpoint file, called after all moves are done
+func (c *MigrationCheckpoint) Remove() error {
+	return os.Remove(c.path)
+}
+
+func (c *MigrationCheckpoint) IsDone(slot int) bool {
+	i, ok := c.index[slot]
+	return ok && c.Moves[i].Done
+}
+
+// MarkDone marks slot as migrated and appends it to checkpoint file
+func (c *MigrationCheckpoint) MarkDone(slot int) error {
+	i, ok := c.index[slot]
+	if !ok {
+		return fmt.Errorf("slot %d is not in checkpoint", slot)
+	}
+	c.Moves[i].Done = true
+	f, err := os.OpenFile(c.path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(checkpointDone{Done: slot})
+	if err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Resolve maps saved moves to current cluster nodes. Pending slots already owned by their target
+// (run was interrupted after assigning the slot) are marked done in memory only, so a dry run never
+// writes, slots owned by a node other than source or target mean the cluster changed since the plan was made.
+func (c *MigrationCheckpoint) Resolve(nodes []*RedisNode) ([]*SlotMove, error) {
+	byID := make(map[string]*RedisNode)
+	var owners [SlotsNum]*RedisNode
+	for _, n := range nodes {
+		byID[n.ID] = n
+		if !n.IsMaster() {
+			continue
+		}
+		for _, s := range n.Slots {
+			for i := s.Start; i <= s.End; i++ {
+				owners[i] = n
+			}
+		}
+	}
+	moves := make([]*SlotMove, 0, len(c.Moves))
+	for i, cm := range c.Moves {
+		src, ok := byID[cm.Source]
+		if !ok {
+			return nil, fmt.Errorf("source %s of slot %d is not in cluster", cm.Source, cm.Slot)
+		}
+		dst, ok := byID[cm.Target]
+		if !ok {
+			return nil, fmt.Errorf("target %s of slot %d is not in cluster", cm.Target, cm.Slot)
+		}
+		moves = append(moves, &SlotMove{Slot: cm.Slot, Source: src, Target: dst, Weight: cm.Weight})
+		if cm.Done || cm.Slot < 0 || cm.Slot >= SlotsNum {
+			continue
+		}
+		switch owners[cm.Slot] {
+		case dst:
+			c.Moves[i].Done = true
+		case src:
+		default:
+			return nil, fmt.Errorf("slot %d is owned by neither %s nor %s, checkpoint is stale", cm.Slot, src.Pod.Name, dst.Pod.Name)
+		}
+	}
+	return moves, nil
+}
+
+// Pending returns count of moves not done
+func (c *MigrationCheckpoint) Pending() int {
+	n := 0
+	for _, mv := range c.Moves {
+		if !mv.Done {
+			n++
+		}
+	}
+	return n
+}
+
+// DefaultCheckpointPath is ~/.kuberc/rebalance/<context>_<namespace>_<cluster>.json, so clusters
+// in the same namespace or in namespaces of the same name in other contexts never share it.
+// Empty if home dir is unknown.
+func DefaultCheckpointPath(kubeContext, namespace, cluster string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	name := strings.Join([]string{kubeContext, namespace, cluster}, "_")
+	// contexts may be arns or urls
+	name = strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '-'
+	}, name)
+	return filepath.Join(home, ".kuberc", "rebalance", name+".json")
+}
@@ -0,0 +1,156 @@
+package redis
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadCheckpointRepair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "checkpoint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rebalance", "cp.json")
+
+	if cp, err := LoadCheckpoint(path, true); err != nil || cp != nil {
+		t.Fatalf("missing checkpoint gives %v %v, want nil nil", cp, err)
+	}
+	a, b := podNode("rc-0", ""), podNode("rc-1", "")
+	moves := []*SlotMove{{Slot: 1, Source: a, Target: b}, {Slot: 2, Source: a, Target: b}, {Slot: 3, Source: a, Target: b}}
+	cp, err := NewCheckpoint(path, BalanceByKeys, moves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.MarkDone(1); err != nil {
+		t.Fatal(err)
+	}
+	if err := cp.MarkDone(4); err == nil {
+		t.Error("slot not in checkpoint should fail")
+	}
+	// interrupted while appending slot 2
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"do`)
+	f.Close()
+	size := func() int64 {
+		st, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return st.Size()
+	}
+	partial := size()
+
+	tests := []struct {
+		repair bool
+		size   int64
+	}{
+		{false, partial},
+		{true, partial - 4},
+	}
+	for _, tt := range tests {
+		cp, err := LoadCheckpoint(path, tt.repair)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !cp.IsDone(1) || cp.IsDone(2) || cp.IsDone(3) || cp.Pending() != 2 || cp.By != BalanceByKeys {
+			t.Errorf("repair %t: wrong checkpoint %+v", tt.repair, cp.Moves)
+		}
+		if s := size(); s != tt.size {
+			t.Errorf("repair %t: file size %d, want %d", tt.repair, s, tt.size)
+		}
+	}
+	// appending after repair keeps file valid
+	if err := cp.MarkDone(2); err != nil {
+		t.Fatal(err)
+	}
+	if cp, err = LoadCheckpoint(path, false); err != nil || cp.Pending() != 1 {
+		t.Fatalf("got %v, %v after repair, want 1 pending", cp, err)
+	}
+	if err := cp.Remove(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckpointResolve(t *testing.T) {
+	a, b, c := podNode("rc-0", ""), podNode("rc-1", ""), podNode("rc-2", "")
+	a.Slots = []SlotRange{{0, 9}}
+	b.Slots = []SlotRange{{10, 19}}
+	c.Slots = []SlotRange{{20, 29}}
+	nodes := []*RedisNode{a, b, c, podNode("rc-3", "rc-0-id")}
+
+	tests := []struct {
+		name  string
+		moves []CheckpointMove
+		// done are slots done after resolve
+		done []int
+		fail bool
+	}{
+		{
+			name:  "slot still on source is pending",
+			moves: []CheckpointMove{{Slot: 1, Source: a.ID, Target: b.ID}},
+		},
+		{
+			name:  "slot already on target is done",
+			moves: []CheckpointMove{{Slot: 1, Source: a.ID, Target: b.ID}, {Slot: 11, Source: a.ID, Target: b.ID}},
+			done:  []int{11},
+		},
+		{
+			name:  "done slot isn't checked",
+			moves: []CheckpointMove{{Slot: 21, Source: a.ID, Target: b.ID, Done: true}},
+			done:  []int{21},
+		},
+		{
+			name:  "slot owned by another master is stale",
+			moves: []CheckpointMove{{Slot: 21, Source: a.ID, Target: b.ID}},
+			fail:  true,
+		},
+		{
+			name:  "unknown source is stale",
+			moves: []CheckpointMove{{Slot: 1, Source: "gone", Target: b.ID}},
+			fail:  true,
+		},
+		{
+			name:  "unknown target is stale",
+			moves: []CheckpointMove{{Slot: 1, Source: a.ID, Target: "gone"}},
+			fail:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cp := &MigrationCheckpoint{path: filepath.Join(os.TempDir(), "never-written.json"), Moves: tt.moves}
+			cp.buildIndex()
+			moves, err := cp.Resolve(nodes)
+			if tt.fail {
+				if err == nil {
+					t.Error("should fail")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(moves) != len(tt.moves) {
+				t.Fatalf("got %d moves, want %d", len(moves), len(tt.moves))
+			}
+			done := make(map[int]bool)
+			for _, s := range tt.done {
+				done[s] = true
+			}
+			for _, mv := range moves {
+				if cp.IsDone(mv.Slot) != done[mv.Slot] {
+					t.Errorf("slot %d done is %t, want %t", mv.Slot, cp.IsDone(mv.Slot), done[mv.Slot])
+				}
+			}
+			// resolve never writes, a dry run may resolve
+			if _, err := os.Stat(cp.path); !os.IsNotExist(err) {
+				t.Errorf("resolve wrote %s", cp.path)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ClientInfo is a connection in client list
+// https://redis.io/commands/client-list
+type ClientInfo struct {
+	ID     string
+	Addr   string
+	Name   string
+	Age    int
+	Idle   int
+	Flags  string
+	Cmd    string
+	User   string
+	Qbuf   int
+	Omem   int
+	TotMem int
+	Fields map[string]string
+}
+
+// IP returns ip part of client addr
+func (c *ClientInfo) IP() string {
+	if i := strings.LastIndex(c.Addr, ":"); i >= 0 {
+		return strings.TrimSuffix(strings.TrimPrefix(c.Addr[:i], "["), "]")
+	}
+	return c.Addr
+}
+
+// IsReplication returns true for master/replica links
+func (c *ClientInfo) IsReplication() bool {
+	return strings.ContainsAny(c.Flags, "MS")
+}
+
+func NewClientInfo(line string) *ClientInfo {
+	c := &ClientInfo{Fields: make(map[string]string)}
+	for _, f := range strings.Fields(line) {
+		parts := strings.SplitN(f, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		c.Fields[parts[0]] = parts[1]
+	}
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(c.Fields[key])
+		return n
+	}
+	c.ID = c.Fields["id"]
+	c.Addr = c.Fields["addr"]
+	c.Name = c.Fields["name"]
+	c.Flags = c.Fields["flags"]
+	c.Cmd = c.Fields["cmd"]
+	c.User = c.Fields["user"]
+	c.Age = atoi("age")
+	c.Idle = atoi("idle")
+	c.Qbuf = atoi("qbuf")
+	c.Omem = atoi("omem")
+	c.TotMem = atoi("tot-mem")
+	return c
+}
+
+func (r *RedisPod) ClientList(ctx context.Context) ([]*ClientInfo, error) {
+	result, err := r.redisCliLocal(ctx, "client list", true)
+	if err != nil {
+		return nil, err
+	}
+	clients := make([]*ClientInfo, 0)
+	for _, line := range strings.Split(result, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			clients = append(clients, NewClientInfo(line))
+		}
+	}
+	return clients, nil
+}
+
+// ClientKill kills connection of id, returns false if it's already gone since listed
+func (r *RedisPod) ClientKill(ctx context.Context, id string) (bool, error) {
+	result, err := r.redisCliLocal(ctx, "client kill id "+id, true)
+	if err != nil {
+		return false, err
+	}
+	switch strings.TrimSpace(result) {
+	case "1":
+		return true, nil
+	case "0":
+		return false, nil
+	}
+	return false, fmt.Errorf("failed to kill client %s on %s: %s", id, r.GetName(), strings.TrimSpace(result))
+}
@@ -0,0 +1,244 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	goredis "github.com/go-redis/redis/v8"
+	"k8s.io/klog/v2"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+)
+
+type ImportOptions struct {
+	// Method is "migrate" (source pod migrates keys to masters directly) or
+	// "restore" (dump from source and restore to masters through port-forward)
+	Method string
+	Match  string
+	Batch  int
+	// Timeout of migrate in milliseconds
+	Timeout int
+	// Copy keeps keys in source
+	Copy bool
+	// Replace existing keys in cluster
+	Replace bool
+}
+
+type ImportResult struct {
+	Scanned  int64
+	Imported int64
+	Failed   int64
+}
+
+// Import scans keys in source (a standalone redis) and imports them into the cluster r belongs to,
+// progress is called after every batch.
+func (r *RedisPod) Import(ctx context.Context, source *RedisPod, opts *ImportOptions, progress func(*ImportResult)) (*ImportResult, error) {
+	if opts.Method != "migrate" && opts.Method != "restore" {
+		return nil, fmt.Errorf("unknown import method %s", opts.Method)
+	}
+	if opts.Batch <= 0 {
+		return nil, errors.New("batch size must > 0")
+	}
+	slots, err := r.ClusterSlots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var owners [SlotsNum]*RedisPod
+	for _, s := range slots {
+		for i := s.Start; i <= s.End; i++ {
+			owners[i] = s.Master
+		}
+	}
+	for i, o := range owners {
+		if o == nil {
+			return nil, fmt.Errorf("slot %d is not covered by cluster", i)
+		}
+	}
+
+	src, fw, err := source.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer fw.Stop()
+	defer src.Close()
+
+	// clients of masters, only used by restore method
+	targets := make(map[string]*goredis.Client)
+	defer func() {
+		for _, t := range targets {
+			t.Close()
+		}
+	}()
+	forwarders := make([]*common.PortForwarder, 0)
+	defer func() {
+		for _, f := range forwarders {
+			f.Stop()
+		}
+	}()
+	target := func(m *RedisPod) (*goredis.Client, error) {
+		if c, ok := targets[m.GetName()]; ok {
+			return c, nil
+		}
+		c, f, err := m.NewClient(ctx)
+		if err != nil {
+			return nil, err
+		}
+		forwarders = append(forwarders, f)
+		targets[m.GetName()] = c
+		return c, nil
+	}
+
+	result := new(ImportResult)
+	var cursor uint64
+	for {
+		keys, next, err := src.Scan(ctx, cursor, opts.Match, int64(opts.Batch)).Result()
+		if err != nil {
+			return result, err
+		}
+		result.Scanned += int64(len(keys))
+		groups := make(map[*RedisPod][]string)
+		for _, k := range keys {
+			o := owners[KeySlot(k)]
+			groups[o] = append(groups[o], k)
+		}
+		for m, group := range groups {
+			var imported int64
+			if opts.Method == "migrate" {
+				imported, err = migrateKeys(ctx, src, m, r.conn.User, r.conn.Password, group, opts)
+			} else {
+				var c *goredis.Client
+				if c, err = target(m); err == nil {
+					imported, err = restoreKeys(ctx, src, c, group, opts)
+				}
+			}
+			if err != nil {
+				return result, err
+			}
+			result.Imported += imported
+			result.Failed += int64(len(group)) - imported
+		}
+		if progress != nil {
+			progress(result)
+		}
+		cursor = next
+		if cursor == 0 {
+			break
+		}
+	}
+	return result, nil
+}
+
+// migrateKeys runs migrate on source to move keys to master, if the batch fails,
+// keys are retried one by one, so one busy key won't fail the whole batch.
+// Keys before the failed one already reached master, with copy their retry fails with BUSYKEY,
+// so it's counted as imported.
+func migrateKeys(ctx context.Context, src *goredis.Client, master *RedisPod, user, password string, keys []string, opts *ImportOptions) (int64, error) {
+	migrate := func(keys []string) error {
+		args := migrateArgs(master.GetIP(), master.port, user, password, opts.Timeout, opts.Copy, opts.Replace, keys)
+		return src.Do(ctx, args...).Err()
+	}
+	if err := migrate(keys); err == nil {
+		return int64(len(keys)), nil
+	} else if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	var imported int64
+	for _, k := range keys {
+		if err := migrate([]string{k}); err != nil {
+			if ctx.Err() != nil {
+				return imported, ctx.Err()
+			}
+			if opts.Copy && !opts.Replace && strings.Contains(err.Error(), "BUSYKEY") {
+				imported++
+				continue
+			}
+			klog.V(1).Infof("failed to migrate key %s to %s: %v", k, master.GetName(), err)
+			continue
+		}
+		imported++
+	}
+	return imported, nil
+}
+
+// migrateArgs builds MIGRATE command moving keys to host:port, with auth of cluster
+func migrateArgs(host string, port int, user, password string, timeout int, copy, replace bool, keys []string) []interface{} {
+	args := []interface{}{"migrate", host, port, "", 0, timeout}
+	if copy {
+		args = append(args, "copy")
+	}
+	if replace {
+		args = append(args, "replace")
+	}
+	if password != "" {
+		if user != "" {
+			args = append(args, "auth2", user, password)
+		} else {
+			args = append(args, "auth", password)
+		}
+	}
+	args = append(args, "keys")
+	for _, k := range keys {
+		args = append(args, k)
+	}
+	return args
+}
+
+// restoreKeys dumps keys from source and restores them to target with ttl kept
+func restoreKeys(ctx context.Context, src, target *goredis.Client, keys []string, opts *ImportOptions) (int64, error) {
+	dumps := make([]*goredis.StringCmd, len(keys))
+	ttls := make([]*goredis.DurationCmd, len(keys))
+	pipe := src.Pipeline()
+	for i, k := range keys {
+		dumps[i] = pipe.Dump(ctx, k)
+		ttls[i] = pipe.PTTL(ctx, k)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != goredis.Nil {
+		return 0, err
+	}
+	restores := make([]*goredis.StatusCmd, len(keys))
+	pipe = target.Pipeline()
+	for i, k := range keys {
+		val, err := dumps[i].Result()
+		if err != nil {
+			// key expired or deleted after scan
+			continue
+		}
+		// -2 is key expired after dump, -1 is key without ttl
+		ttl := ttls[i].Val()
+		if ttl == -2 {
+			continue
+		}
+		if ttl == -1 {
+			ttl = 0
+		}
+		if opts.Replace {
+			restores[i] = pipe.RestoreReplace(ctx, k, ttl, val)
+		} else {
+			restores[i] = pipe.Restore(ctx, k, ttl, val)
+		}
+	}
+	if _, err := pipe.Exec(ctx); err != nil && ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	var imported int64
+	restored := make([]string, 0, len(keys))
+	for i, cmd := range restores {
+		if cmd == nil {
+			continue
+		}
+		if err := cmd.Err(); err != nil {
+			klog.V(1).Infof("failed to restore key %s: %v", keys[i], err)
+			continue
+		}
+		imported++
+		restored = append(restored, keys[i])
+	}
+	if !opts.Copy && len(restored) > 0 {
+		if err := src.Del(ctx, restored...).Err(); err != nil {
+			return imported, err
+		}
+	}
+	return imported, nil
+}
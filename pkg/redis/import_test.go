@@ -0,0 +1,31 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMigrateArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		copy     bool
+		replace  bool
+		want     string
+	}{
+		{"no auth", "", "", false, false, "[migrate 10.0.0.1 6379  0 5000 keys a b]"},
+		{"copy replace", "", "", true, true, "[migrate 10.0.0.1 6379  0 5000 copy replace keys a b]"},
+		{"password", "", "secret", true, false, "[migrate 10.0.0.1 6379  0 5000 copy auth secret keys a b]"},
+		{"acl user", "admin", "secret", false, true, "[migrate 10.0.0.1 6379  0 5000 replace auth2 admin secret keys a b]"},
+		{"user without password", "admin", "", false, false, "[migrate 10.0.0.1 6379  0 5000 keys a b]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fmt.Sprint(migrateArgs("10.0.0.1", 6379, tt.user, tt.password, 5000, tt.copy, tt.replace, []string{"a", "b"}))
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
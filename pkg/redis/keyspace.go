@@ -0,0 +1,137 @@
+package redis
+
+import (
+	"context"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Keyspace is db0 stats of a node, Keys of slaves comes from DBSIZE
+type Keyspace struct {
+	Node    *RedisNode
+	Keys    int64
+	Expires int64
+	// AvgTTL is average ttl of keys with expire in milliseconds
+	AvgTTL int64
+	Err    error
+}
+
+// SlotKeys is keys count of a slot
+type SlotKeys struct {
+	Slot   int
+	Master string
+	Keys   int64
+}
+
+// parseKeyspace parses "db0:keys=1,expires=0,avg_ttl=0" of INFO keyspace into ks
+func parseKeyspace(info map[string]string, ks *Keyspace) {
+	for _, f := range strings.Split(info["db0"], ",") {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		v, _ := strconv.ParseInt(kv[1], 10, 64)
+		switch kv[0] {
+		case "keys":
+			ks.Keys = v
+		case "expires":
+			ks.Expires = v
+		case "avg_ttl":
+			ks.AvgTTL = v
+		}
+	}
+}
+
+// LoadKeyspace collects INFO keyspace of masters and DBSIZE of slaves in nodes concurrently,
+// result is in the same order as nodes.
+func (r *RedisPod) LoadKeyspace(ctx context.Context, nodes []*RedisNode) []*Keyspace {
+	res := make([]*Keyspace, len(nodes))
+	sem := make(chan struct{}, probeConcurrency)
+	var wg sync.WaitGroup
+	for i, n := range nodes {
+		res[i] = &Keyspace{Node: n}
+		wg.Add(1)
+		go func(ks *Keyspace) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			p := NewRedisPodWithPod(ks.Node.Pod, r.redisContainerName, r.port, r.conn, r.clientset, r.restcfg)
+			if !ks.Node.IsMaster() {
+				ks.Keys, ks.Err = p.DBSize(ctx)
+				return
+			}
+			info, err := p.Info(ctx, "keyspace")
+			if err != nil {
+				ks.Err = err
+				return
+			}
+			parseKeyspace(info, ks)
+		}(res[i])
+	}
+	wg.Wait()
+	return res
+}
+
+// SampleSlotKeys runs CLUSTER COUNTKEYSINSLOT on sample slots owned by master through port-forward,
+// all owned slots are counted if sample <= 0. Result is sorted by keys desc.
+func (r *RedisPod) SampleSlotKeys(ctx context.Context, master *RedisNode, sample int) ([]SlotKeys, error) {
+	slots := make([]int, 0, master.SlotsCount())
+	for _, s := range master.Slots {
+		for i := s.Start; i <= s.End; i++ {
+			slots = append(slots, i)
+		}
+	}
+	if sample > 0 && sample < len(slots) {
+		rand.Shuffle(len(slots), func(i, j int) { slots[i], slots[j] = slots[j], slots[i] })
+		slots = slots[:sample]
+	}
+	if len(slots) == 0 {
+		return nil, nil
+	}
+	p := NewRedisPodWithPod(master.Pod, r.redisContainerName, r.port, r.conn, r.clientset, r.restcfg)
+	client, fw, err := p.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer fw.Stop()
+	defer client.Close()
+	pipe := client.Pipeline()
+	for _, s := range slots {
+		pipe.ClusterCountKeysInSlot(ctx, s)
+	}
+	cmds, err := pipe.Exec(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]SlotKeys, len(slots))
+	for i, s := range slots {
+		res[i] = SlotKeys{Slot: s, Master: master.Pod.Name}
+		// pipeline returns commands in order they were queued
+		if c, ok := cmds[i].(interface{ Val() int64 }); ok {
+			res[i].Keys = c.Val()
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Keys > res[j].Keys })
+	return res, nil
+}
+
+// Skew is max / average of values, 1 means evenly distributed, 0 if all values are 0
+func Skew(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var max, sum float64
+	for _, v := range values {
+		sum += v
+		if v > max {
+			max = v
+		}
+	}
+	if sum == 0 {
+		return 0
+	}
+	return max * float64(len(values)) / sum
+}
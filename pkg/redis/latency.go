@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type LatencyEvent struct {
+	Name   string
+	Time   time.Time
+	Latest time.Duration
+	Max    time.Duration
+}
+
+type LatencySample struct {
+	Time    time.Time
+	Latency time.Duration
+}
+
+// LatencyLatest returns latest latency samples of all events
+// https://redis.io/commands/latency-latest
+func (r *RedisPod) LatencyLatest(ctx context.Context) ([]*LatencyEvent, error) {
+	result, err := r.redisCliLocal(ctx, "latency latest", false)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := parseReply(result)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("wrong latency latest %s", result)
+	}
+	events := make([]*LatencyEvent, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 4 {
+			return nil, fmt.Errorf("wrong latency event %v", item)
+		}
+		ts, err := replyInt(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		latest, err := replyInt(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		max, err := replyInt(fields[3])
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, &LatencyEvent{Name: replyString(fields[0]), Time: time.Unix(ts, 0),
+			Latest: time.Duration(latest) * time.Millisecond, Max: time.Duration(max) * time.Millisecond})
+	}
+	return events, nil
+}
+
+// https://redis.io/commands/latency-history
+func (r *RedisPod) LatencyHistory(ctx context.Context, event string) ([]*LatencySample, error) {
+	result, err := r.redisCliLocal(ctx, "latency history "+event, false)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := parseReply(result)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("wrong latency history %s", result)
+	}
+	samples := make([]*LatencySample, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 2 {
+			return nil, fmt.Errorf("wrong latency sample %v", item)
+		}
+		ts, err := replyInt(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		latency, err := replyInt(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, &LatencySample{Time: time.Unix(ts, 0), Latency: time.Duration(latency) * time.Millisecond})
+	}
+	return samples, nil
+}
+
+func (r *RedisPod) LatencyDoctor(ctx context.Context) (string, error) {
+	return r.redisCliLocal(ctx, "latency doctor", true)
+}
@@ -0,0 +1,160 @@
+package redis
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/klog/v2"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+)
+
+// probeConcurrency limits concurrent exec when probing pods
+const probeConcurrency = 10
+
+// ClusterSummary describes a redis cluster found among pods
+type ClusterSummary struct {
+	Namespace    string
+	StatefulSets []string
+	Pods         []string
+	// Nodes is the number of nodes known by cluster, can be more than Pods
+	Nodes    int
+	Masters  int
+	Replicas int
+	State    string
+	Version  string
+}
+
+type probeResult struct {
+	pod   *RedisPod
+	myID  string
+	nodes []*RedisNode
+}
+
+// probe returns cluster nodes seen by r, nil if r isn't a cluster member
+func (r *RedisPod) probe(ctx context.Context) *probeResult {
+	result, err := r.redisCliLocal(ctx, "cluster nodes", false)
+	if err != nil {
+		klog.V(2).Infof("probe %s/%s: %v", r.pod.Namespace, r.pod.Name, err)
+		return nil
+	}
+	res := &probeResult{pod: r}
+	for _, line := range strings.Split(result, "\n") {
+		// cluster disabled instances reply an error
+		if len(strings.Fields(line)) < 8 {
+			continue
+		}
+		n := NewRedisNode(line)
+		if n.HasFlag("myself") {
+			res.myID = n.ID
+		}
+		res.nodes = append(res.nodes, n)
+	}
+	if res.myID == "" {
+		return nil
+	}
+	return res
+}
+
+// FindClusters probes pods with cluster nodes and groups members into clusters by node id membership
+func FindClusters(ctx context.Context, pods []corev1.Pod, container string, port int, conn *common.ConnConfig, clientset *kubernetes.Clientset, restcfg *restclient.Config) []*ClusterSummary {
+	results := make([]*probeResult, len(pods))
+	sem := make(chan struct{}, probeConcurrency)
+	var wg sync.WaitGroup
+	for i := range pods {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i] = NewRedisPodWithPod(&pods[i], container, port, conn, clientset, restcfg).probe(ctx)
+		}(i)
+	}
+	wg.Wait()
+
+	// union node ids seen by every member, node ids are only unique within a namespace here
+	parent := make(map[string]string)
+	var find func(string) string
+	find = func(id string) string {
+		if p, ok := parent[id]; ok && p != id {
+			parent[id] = find(p)
+			return parent[id]
+		}
+		parent[id] = id
+		return id
+	}
+	key := func(r *probeResult, id string) string {
+		return r.pod.pod.Namespace + "/" + id
+	}
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		root := find(key(r, r.myID))
+		for _, n := range r.nodes {
+			parent[find(key(r, n.ID))] = root
+		}
+	}
+	groups := make(map[string][]*probeResult)
+	roots := make([]string, 0)
+	for _, r := range results {
+		if r == nil {
+			continue
+		}
+		root := find(key(r, r.myID))
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], r)
+	}
+
+	clusters := make([]*ClusterSummary, 0, len(roots))
+	for _, root := range roots {
+		members := groups[root]
+		sort.Slice(members, func(i, j int) bool { return members[i].pod.GetName() < members[j].pod.GetName() })
+		c := &ClusterSummary{Namespace: members[0].pod.pod.Namespace}
+		stsSet := make(map[string]bool)
+		// the member knowing most nodes describes the cluster
+		view := members[0]
+		for _, m := range members {
+			c.Pods = append(c.Pods, m.pod.GetName())
+			for _, o := range m.pod.pod.OwnerReferences {
+				if o.Kind == "StatefulSet" && !stsSet[o.Name] {
+					stsSet[o.Name] = true
+					c.StatefulSets = append(c.StatefulSets, o.Name)
+				}
+			}
+			if len(m.nodes) > len(view.nodes) {
+				view = m
+			}
+		}
+		sort.Strings(c.StatefulSets)
+		c.Nodes = len(view.nodes)
+		for _, n := range view.nodes {
+			if n.IsMaster() {
+				c.Masters++
+			} else {
+				c.Replicas++
+			}
+		}
+		if info, err := view.pod.redisCliLocal(ctx, "cluster info", true); err == nil {
+			c.State = parseInfo(info)["cluster_state"]
+		}
+		if info, err := view.pod.Info(ctx, "server"); err == nil {
+			c.Version = info["redis_version"]
+		}
+		clusters = append(clusters, c)
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Namespace != clusters[j].Namespace {
+			return clusters[i].Namespace < clusters[j].Namespace
+		}
+		return clusters[i].Pods[0] < clusters[j].Pods[0]
+	})
+	return clusters
+}
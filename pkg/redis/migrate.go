@@ -0,0 +1,219 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	goredis "github.com/go-redis/redis/v8"
+	"k8s.io/klog/v2"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+)
+
+// SlotMove moves a slot from Source to Target
+type SlotMove struct {
+	Slot   int
+	Source *RedisNode
+	Target *RedisNode
+	// Weight is keys or bytes of slot measured when planning
+	Weight int64
+}
+
+func (m *SlotMove) String() string {
+	return fmt.Sprintf("slot %d: %s -> %s", m.Slot, m.Source.Pod.Name, m.Target.Pod.Name)
+}
+
+// SlotMigrator migrates slots between masters without redis-cli, following the protocol in
+// https://redis.io/commands/cluster-setslot: SETSLOT IMPORTING on target, MIGRATING on source,
+// GETKEYSINSLOT + MIGRATE in batches, then SETSLOT NODE on target, source and the other masters
+// it is created with, see assign.
+type SlotMigrator struct {
+	entry   *RedisPod
+	masters []*RedisNode
+	clients map[string]*goredis.Client
+	fws     []*common.PortForwarder
+	// Batch is keys count of a single MIGRATE
+	Batch int
+	// Timeout of MIGRATE in milliseconds
+	Timeout int
+	// Replace existing keys in target
+	Replace bool
+	// MaxKeysPerSec throttles migration, 0 is unlimited
+	MaxKeysPerSec int
+	// Progress is called after every batch and when a slot is done
+	Progress func(*SlotProgress)
+
+	throttleStart time.Time
+	throttled     int64
+}
+
+// NewSlotMigrator creates migrator between masters, connections are opened through port-forward on first use
+func (r *RedisPod) NewSlotMigrator(masters []*RedisNode, batch, timeout int, replace bool) *SlotMigrator {
+	return &SlotMigrator{entry: r, masters: masters, clients: make(map[string]*goredis.Client),
+		Batch: batch, Timeout: timeout, Replace: replace}
+}
+
+// Close closes all connections and port-forwards
+func (m *SlotMigrator) Close() {
+	for _, c := range m.clients {
+		c.Close()
+	}
+	for _, fw := range m.fws {
+		fw.Stop()
+	}
+	m.clients = make(map[string]*goredis.Client)
+	m.fws = nil
+}
+
+func (m *SlotMigrator) client(ctx context.Context, n *RedisNode) (*goredis.Client, error) {
+	if c, ok := m.clients[n.ID]; ok {
+		return c, nil
+	}
+	p := NewRedisPodWithPod(n.Pod, m.entry.redisContainerName, m.entry.port, m.entry.conn, m.entry.clientset, m.entry.restcfg)
+	c, fw, err := p.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m.fws = append(m.fws, fw)
+	m.clients[n.ID] = c
+	return c, nil
+}
+
+// SlotProgress is state of a slot being migrated
+type SlotProgress struct {
+	Move *SlotMove
+	// Index is 1-based position of move in run, Total is moves in run
+	Index, Total int
+	// Expected is keys in slot when migration started
+	Expected int64
+	Keys     int64
+	Elapsed  time.Duration
+	Done     bool
+}
+
+// migrateSlot moves all keys of p.Move.Slot to target and assigns the slot to it, reporting progress
+func (m *SlotMigrator) migrateSlot(ctx context.Context, p *SlotProgress) error {
+	mv := p.Move
+	started := time.Now()
+	src, err := m.client(ctx, mv.Source)
+	if err != nil {
+		return err
+	}
+	dst, err := m.client(ctx, mv.Target)
+	if err != nil {
+		return err
+	}
+	if err := dst.Do(ctx, "cluster", "setslot", mv.Slot, "importing", mv.Source.ID).Err(); err != nil {
+		return fmt.Errorf("failed to set slot %d importing on %s: %v", mv.Slot, mv.Target.Pod.Name, err)
+	}
+	if err := src.Do(ctx, "cluster", "setslot", mv.Slot, "migrating", mv.Target.ID).Err(); err != nil {
+		return fmt.Errorf("failed to set slot %d migrating on %s: %v", mv.Slot, mv.Source.Pod.Name, err)
+	}
+	if p.Expected, err = src.ClusterCountKeysInSlot(ctx, mv.Slot).Result(); err != nil {
+		return err
+	}
+	batch := m.Batch
+	if m.MaxKeysPerSec > 0 && m.MaxKeysPerSec < batch {
+		batch = m.MaxKeysPerSec
+	}
+	for {
+		keys, err := src.ClusterGetKeysInSlot(ctx, mv.Slot, batch).Result()
+		if err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			break
+		}
+		if err := m.throttle(ctx, len(keys)); err != nil {
+			return err
+		}
+		args := migrateArgs(mv.Target.IP, mv.Target.Port, m.entry.conn.User, m.entry.conn.Password, m.Timeout, false, m.Replace, keys)
+		if err := src.Do(ctx, args...).Err(); err != nil {
+			if strings.HasPrefix(err.Error(), "BUSYKEY") {
+				return fmt.Errorf("failed to migrate slot %d: %v, pass replace to overwrite keys in target", mv.Slot, err)
+			}
+			return fmt.Errorf("failed to migrate slot %d: %v", mv.Slot, err)
+		}
+		p.Keys += int64(len(keys))
+		p.Elapsed = time.Since(started)
+		if m.Progress != nil {
+			m.Progress(p)
+		}
+	}
+	if err := m.assign(ctx, mv); err != nil {
+		return err
+	}
+	p.Elapsed = time.Since(started)
+	p.Done = true
+	if m.Progress != nil {
+		m.Progress(p)
+	}
+	return nil
+}
+
+// throttle sleeps until migrating n more keys keeps rate under MaxKeysPerSec
+func (m *SlotMigrator) throttle(ctx context.Context, n int) error {
+	if m.MaxKeysPerSec <= 0 {
+		return nil
+	}
+	if m.throttleStart.IsZero() {
+		m.throttleStart = time.Now()
+	}
+	wait := time.Until(m.throttleStart.Add(time.Duration(m.throttled) * time.Second / time.Duration(m.MaxKeysPerSec)))
+	m.throttled += int64(n)
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// Run migrates moves in order, moves already done in checkpoint are skipped and every finished
+// slot is saved into it, so an interrupted run can be resumed with the same checkpoint.
+func (m *SlotMigrator) Run(ctx context.Context, moves []*SlotMove, cp *MigrationCheckpoint) error {
+	for i, mv := range moves {
+		if cp != nil && cp.IsDone(mv.Slot) {
+			continue
+		}
+		if err := m.migrateSlot(ctx, &SlotProgress{Move: mv, Index: i + 1, Total: len(moves)}); err != nil {
+			return err
+		}
+		if cp != nil {
+			if err := cp.MarkDone(mv.Slot); err != nil {
+				return fmt.Errorf("slot %d is migrated, but failed to save checkpoint: %v", mv.Slot, err)
+			}
+		}
+	}
+	return nil
+}
+
+// assign sets owner of slot to target on target, source, then other masters of migrator. Only target and
+// source must succeed. Masters not passed to migrator (rebalance skips failed ones), masters failing here
+// and slaves learn the new owner through gossip, as the higher config epoch of target wins.
+func (m *SlotMigrator) assign(ctx context.Context, mv *SlotMove) error {
+	nodes := []*RedisNode{mv.Target, mv.Source}
+	for _, n := range m.masters {
+		if n.ID != mv.Source.ID && n.ID != mv.Target.ID {
+			nodes = append(nodes, n)
+		}
+	}
+	for i, n := range nodes {
+		c, err := m.client(ctx, n)
+		if err == nil {
+			err = c.Do(ctx, "cluster", "setslot", mv.Slot, "node", mv.Target.ID).Err()
+		}
+		if err != nil {
+			if i < 2 {
+				return fmt.Errorf("failed to set slot %d node on %s: %v", mv.Slot, n.Pod.Name, err)
+			}
+			klog.Warningf("failed to set slot %d node on %s: %v", mv.Slot, n.Pod.Name, err)
+		}
+	}
+	return nil
+}
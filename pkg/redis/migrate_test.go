@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestThrottle(t *testing.T) {
+	ctx := context.Background()
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+
+	tests := []struct {
+		name       string
+		maxPerSec  int
+		started    time.Duration
+		throttled  int64
+		ctx        context.Context
+		wantErr    bool
+		wantWaitGt time.Duration
+	}{
+		{name: "unlimited", maxPerSec: 0, throttled: 1000000, ctx: ctx},
+		{name: "first batch never waits", maxPerSec: 10, ctx: ctx},
+		{name: "under rate", maxPerSec: 100, started: time.Second, throttled: 50, ctx: ctx},
+		{name: "over rate waits", maxPerSec: 100, throttled: 20, ctx: ctx, wantWaitGt: 150 * time.Millisecond},
+		{name: "cancelled while waiting", maxPerSec: 1, throttled: 100, ctx: cancelled, wantErr: true},
+		{name: "cancelled without wait", maxPerSec: 100, started: time.Second, throttled: 50, ctx: cancelled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &SlotMigrator{MaxKeysPerSec: tt.maxPerSec, throttled: tt.throttled}
+			if tt.throttled > 0 {
+				m.throttleStart = time.Now().Add(-tt.started)
+			}
+			start := time.Now()
+			err := m.throttle(tt.ctx, 10)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			elapsed := time.Since(start)
+			if elapsed < tt.wantWaitGt {
+				t.Errorf("waited %s, want over %s", elapsed, tt.wantWaitGt)
+			}
+			if tt.wantWaitGt == 0 && elapsed > 100*time.Millisecond {
+				t.Errorf("waited %s, want no wait", elapsed)
+			}
+			if tt.maxPerSec > 0 && m.throttled != tt.throttled+10 {
+				t.Errorf("throttled %d, want %d", m.throttled, tt.throttled+10)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+)
+
+// MonitorLine is a command in monitor output, eg:
+// 1339518083.107412 [0 127.0.0.1:60866] "keys" "*"
+type MonitorLine struct {
+	Raw    string
+	Client string
+	Args   []string
+}
+
+func ParseMonitorLine(line string) *MonitorLine {
+	m := &MonitorLine{Raw: line, Args: make([]string, 0)}
+	start := strings.Index(line, "[")
+	end := strings.Index(line, "]")
+	if start < 0 || end < start {
+		return m
+	}
+	if parts := strings.Fields(line[start+1 : end]); len(parts) == 2 {
+		m.Client = parts[1]
+	}
+	rest := line[end+1:]
+	for {
+		i := strings.Index(rest, `"`)
+		if i < 0 {
+			break
+		}
+		// find closing quote, skipping escaped ones
+		j := i + 1
+		for j < len(rest) && rest[j] != '"' {
+			if rest[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		if j >= len(rest) {
+			break
+		}
+		arg, err := strconv.Unquote(rest[i : j+1])
+		if err != nil {
+			arg = rest[i+1 : j]
+		}
+		m.Args = append(m.Args, arg)
+		rest = rest[j+1:]
+	}
+	return m
+}
+
+// Monitor runs monitor on pod through port-forward, fn is called for every line until ctx is done
+func (r *RedisPod) Monitor(ctx context.Context, fn func(*MonitorLine)) error {
+	fw := common.NewPortForwarder(r.clientset, r.restcfg, r.pod, r.port, 0)
+	if err := fw.Start(ctx); err != nil {
+		return err
+	}
+	defer fw.Stop()
+	c, err := r.conn.Dial(ctx, fmt.Sprintf("localhost:%d", fw.LocalPort))
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	go func() {
+		<-ctx.Done()
+		c.Close()
+	}()
+	if err := common.WriteCommand(c, "MONITOR"); err != nil {
+		return err
+	}
+	reader := bufio.NewReader(c)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(line, "+OK") {
+		return fmt.Errorf("failed to run monitor on %s: %s", r.GetName(), strings.TrimSpace(line))
+	}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		fn(ParseMonitorLine(strings.TrimPrefix(strings.TrimRight(line, "\r\n"), "+")))
+	}
+}
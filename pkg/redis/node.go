@@ -10,20 +10,47 @@ import (
 	"strings"
 )
 
+type SlotRange struct {
+	Start int
+	End   int
+}
+
+func (s SlotRange) Count() int {
+	return s.End - s.Start + 1
+}
+
+func (s SlotRange) String() string {
+	if s.Start == s.End {
+		return strconv.Itoa(s.Start)
+	}
+	return fmt.Sprintf("%d-%d", s.Start, s.End)
+}
+
 type RedisNode struct {
 	ID        string
 	Pod       *corev1.Pod
 	IP        string
+	Port      int
+	CPort     int
+	Hostname  string
 	Flags     []string
 	MasterID  string
 	Epoch     int
 	LinkState string
-	Slots     []string
+	Slots     []SlotRange
+	Migrating map[int]string // slot -> target node id
+	Importing map[int]string // slot -> source node id
+	// Replication is only filled by LoadReplication
+	Replication *Replication
 }
 
 func (n *RedisNode) IsMaster() bool {
+	return n.HasFlag("master")
+}
+
+func (n *RedisNode) HasFlag(flag string) bool {
 	for _, f := range n.Flags {
-		if f == "master" {
+		if f == flag {
 			return true
 		}
 	}
@@ -32,15 +59,8 @@ func (n *RedisNode) IsMaster() bool {
 
 func (n *RedisNode) SlotsCount() int {
 	count := 0
-	for _, s := range n.Slots{
-		if strings.Contains(s, "-"){
-			parts := strings.Split(s, "-")
-			start, _ := strconv.Atoi(parts[0])
-			end, _ := strconv.Atoi(parts[1])
-			count += (end - start + 1)
-		} else {
-			count += 1
-		}
+	for _, s := range n.Slots {
+		count += s.Count()
 	}
 	return count
 }
@@ -52,24 +72,84 @@ func (n *RedisNode) String() string {
 // https://redis.io/commands/cluster-nodes
 func NewRedisNode(info string) *RedisNode {
 	parts := strings.Split(info, " ")
-	ip := strings.Split(parts[1], ":")[0]
+	ip, port, cport, hostname := parseNodeAddr(parts[1])
 	flags := strings.Split(parts[2], ",")
 	masterID := ""
 	if parts[3] != "-" {
-		masterID = parts[3] 
+		masterID = parts[3]
 	}
 	epoch, _ := strconv.Atoi(parts[6])
-	slots := make([]string, 0, 1)
-	for _, slot := range parts[8:] {
-		slots = append(slots, strings.TrimSpace(slot))
-	}
-	return &RedisNode{
+	node := &RedisNode{
 		ID:        parts[0],
 		IP:        ip,
+		Port:      port,
+		CPort:     cport,
+		Hostname:  hostname,
 		Flags:     flags,
-		MasterID: masterID,
+		MasterID:  masterID,
 		Epoch:     epoch,
 		LinkState: parts[7],
-		Slots:     slots,
+		Slots:     make([]SlotRange, 0, 1),
+		Migrating: make(map[int]string),
+		Importing: make(map[int]string),
+	}
+	for _, slot := range parts[8:] {
+		node.parseSlot(strings.TrimSpace(slot))
+	}
+	return node
+}
+
+// parseNodeAddr parses address field in cluster nodes output: ip:port@cport,
+// redis 7 appends hostname and auxiliary fields: ip:port@cport,hostname,shard-id=xxx.
+// ip can be an ipv6 address, or empty for nodes in handshake.
+func parseNodeAddr(addr string) (ip string, port int, cport int, hostname string) {
+	fields := strings.Split(addr, ",")
+	if len(fields) > 1 && !strings.Contains(fields[1], "=") {
+		hostname = fields[1]
+	}
+	addr = fields[0]
+	if i := strings.LastIndex(addr, "@"); i >= 0 {
+		cport, _ = strconv.Atoi(addr[i+1:])
+		addr = addr[:i]
+	}
+	if i := strings.LastIndex(addr, ":"); i >= 0 {
+		port, _ = strconv.Atoi(addr[i+1:])
+		addr = addr[:i]
+	}
+	ip = strings.TrimSuffix(strings.TrimPrefix(addr, "["), "]")
+	return
+}
+
+// parseSlot handles slot entries in cluster nodes output, they can be:
+// a single slot "93", a range "0-5460", a migrating slot "[93->-<node id>]"
+// or an importing slot "[93-<-<node id>]"
+func (n *RedisNode) parseSlot(s string) {
+	if s == "" {
+		return
+	}
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		s = s[1 : len(s)-1]
+		if parts := strings.SplitN(s, "->-", 2); len(parts) == 2 {
+			if slot, err := strconv.Atoi(parts[0]); err == nil {
+				n.Migrating[slot] = parts[1]
+			}
+		} else if parts := strings.SplitN(s, "-<-", 2); len(parts) == 2 {
+			if slot, err := strconv.Atoi(parts[0]); err == nil {
+				n.Importing[slot] = parts[1]
+			}
+		}
+		return
+	}
+	parts := strings.SplitN(s, "-", 2)
+	start, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return
+	}
+	end := start
+	if len(parts) == 2 {
+		if end, err = strconv.Atoi(parts[1]); err != nil {
+			return
+		}
 	}
+	n.Slots = append(n.Slots, SlotRange{Start: start, End: end})
 }
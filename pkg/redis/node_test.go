@@ -0,0 +1,46 @@
+package redis
+
+import "testing"
+
+func TestParseNodeAddr(t *testing.T) {
+	tests := []struct {
+		addr     string
+		ip       string
+		port     int
+		cport    int
+		hostname string
+	}{
+		{"10.0.0.1:6379@16379", "10.0.0.1", 6379, 16379, ""},
+		{"10.0.0.1:6379", "10.0.0.1", 6379, 0, ""},
+		{"10.0.0.1:6379@16379,rc-0.rc.default.svc", "10.0.0.1", 6379, 16379, "rc-0.rc.default.svc"},
+		{"10.0.0.1:6379@16379,,shard-id=abc", "10.0.0.1", 6379, 16379, ""},
+		{"10.0.0.1:6379@16379,rc-0,shard-id=abc", "10.0.0.1", 6379, 16379, "rc-0"},
+		{"10.0.0.1:6379@16379,shard-id=abc", "10.0.0.1", 6379, 16379, ""},
+		{"fd00::1:6379@16379", "fd00::1", 6379, 16379, ""},
+		{"[fd00::1]:6379@16379", "fd00::1", 6379, 16379, ""},
+		{"fd00:10:244::5:6379@16379,rc-1", "fd00:10:244::5", 6379, 16379, "rc-1"},
+		{":6379@16379", "", 6379, 16379, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			ip, port, cport, hostname := parseNodeAddr(tt.addr)
+			if ip != tt.ip || port != tt.port || cport != tt.cport || hostname != tt.hostname {
+				t.Errorf("got %q %d %d %q, want %q %d %d %q", ip, port, cport, hostname, tt.ip, tt.port, tt.cport, tt.hostname)
+			}
+		})
+	}
+}
+
+func TestNewRedisNode(t *testing.T) {
+	n := NewRedisNode("07c37dfeb235213a872192d90877d0cd55635b91 10.0.0.2:6379@16379,rc-1 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected")
+	if n.IP != "10.0.0.2" || n.Hostname != "rc-1" || n.IsMaster() || n.MasterID != "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca" {
+		t.Errorf("wrong slave %+v", n)
+	}
+	n = NewRedisNode("e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca [fd00::1]:6379@16379 myself,master - 0 0 1 connected 0-5460 5462 [5461->-07c37dfeb235213a872192d90877d0cd55635b91]")
+	if n.IP != "fd00::1" || !n.IsMaster() || n.SlotsCount() != 5462 {
+		t.Errorf("wrong master %+v, slots %d", n, n.SlotsCount())
+	}
+	if n.Migrating[5461] != "07c37dfeb235213a872192d90877d0cd55635b91" {
+		t.Errorf("wrong migrating %v", n.Migrating)
+	}
+}
@@ -0,0 +1,168 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+)
+
+// DiskUsage is `df` output of a directory, sizes in bytes
+type DiskUsage struct {
+	Filesystem string
+	Total      int64
+	Used       int64
+	Available  int64
+	MountedOn  string
+}
+
+// UsedPercent of the filesystem
+func (d *DiskUsage) UsedPercent() int {
+	if d.Total == 0 {
+		return 0
+	}
+	return int(d.Used * 100 / d.Total)
+}
+
+// PersistenceStatus is rdb/aof config and state of a node, with disk usage of its data dir
+type PersistenceStatus struct {
+	// Info is INFO persistence
+	Info        map[string]string
+	Save        string
+	AppendOnly  string
+	AppendFsync string
+	Dir         string
+	Disk        *DiskUsage
+	// PVC mounted on Dir, empty if Dir isn't on a PVC
+	PVC         string
+	PVCCapacity int64
+	// Errors of df and pvc lookup, rdb/aof state is still reported
+	Errors []string
+}
+
+// LastSave returns time of last successful save, zero time if it's unknown
+func (s *PersistenceStatus) LastSave() time.Time {
+	ts, _ := strconv.ParseInt(s.Info["rdb_last_save_time"], 10, 64)
+	if ts <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(ts, 0)
+}
+
+// Problems lists failed saves and writes, aof rewrite errors and disk pressure over diskThreshold percent
+func (s *PersistenceStatus) Problems(diskThreshold int) []string {
+	problems := append([]string{}, s.Errors...)
+	if v := s.Info["rdb_last_bgsave_status"]; v != "" && v != "ok" {
+		problems = append(problems, "last bgsave "+v)
+	}
+	if v := s.Info["aof_last_bgrewrite_status"]; s.Info["aof_enabled"] == "1" && v != "" && v != "ok" {
+		problems = append(problems, "last aof rewrite "+v)
+	}
+	if v := s.Info["aof_last_write_status"]; s.Info["aof_enabled"] == "1" && v != "" && v != "ok" {
+		problems = append(problems, "last aof write "+v)
+	}
+	if s.Save == "" && s.Info["aof_enabled"] != "1" {
+		problems = append(problems, "neither rdb nor aof is enabled")
+	}
+	if s.Disk != nil {
+		if p := s.Disk.UsedPercent(); p >= diskThreshold {
+			problems = append(problems, fmt.Sprintf("disk of %s is %d%% used", s.Dir, p))
+		}
+		if s.PVCCapacity > 0 && s.Disk.Total < s.PVCCapacity*9/10 {
+			problems = append(problems, fmt.Sprintf("filesystem (%s) is smaller than pvc %s (%s), resize pending?",
+				common.FormatBytes(s.Disk.Total), s.PVC, common.FormatBytes(s.PVCCapacity)))
+		}
+	}
+	if s.PVC == "" {
+		problems = append(problems, fmt.Sprintf("data dir %s is not on a pvc", s.Dir))
+	}
+	return problems
+}
+
+// Persistence collects persistence status of r
+func (r *RedisPod) Persistence(ctx context.Context) (*PersistenceStatus, error) {
+	info, err := r.Info(ctx, "persistence")
+	if err != nil {
+		return nil, err
+	}
+	s := &PersistenceStatus{Info: info}
+	for key, v := range map[string]*string{"save": &s.Save, "appendonly": &s.AppendOnly, "appendfsync": &s.AppendFsync, "dir": &s.Dir} {
+		if *v, err = r.ConfigGetValue(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	if s.Disk, err = r.diskUsage(ctx, s.Dir); err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("can't get disk usage of %s: %v", s.Dir, err))
+	}
+	if err := r.findPVC(ctx, s); err != nil {
+		s.Errors = append(s.Errors, fmt.Sprintf("can't get pvc %s: %v", s.PVC, err))
+	}
+	return s, nil
+}
+
+// diskUsage runs df in redis container
+func (r *RedisPod) diskUsage(ctx context.Context, dir string) (*DiskUsage, error) {
+	result, err := common.Execute(ctx, r.clientset, r.restcfg, r.execTarget(), "df -Pk "+common.ShellQuote(dir), false, false)
+	if err != nil {
+		return nil, err
+	}
+	lines := strings.Split(strings.TrimSpace(result), "\n")
+	if len(lines) < 2 {
+		return nil, fmt.Errorf("wrong df output: %s", result)
+	}
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(fields) < 6 {
+		return nil, fmt.Errorf("wrong df output: %s", result)
+	}
+	d := &DiskUsage{Filesystem: fields[0], MountedOn: fields[5]}
+	for i, v := range []*int64{&d.Total, &d.Used, &d.Available} {
+		kb, err := strconv.ParseInt(fields[i+1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("wrong df output: %s", result)
+		}
+		*v = kb * 1024
+	}
+	return d, nil
+}
+
+// findPVC finds pvc mounted on the longest mount path containing data dir of redis container
+func (r *RedisPod) findPVC(ctx context.Context, s *PersistenceStatus) error {
+	var container *corev1.Container
+	for i, c := range r.pod.Spec.Containers {
+		if c.Name == r.redisContainerName || (r.redisContainerName == "" && i == 0) {
+			container = &r.pod.Spec.Containers[i]
+		}
+	}
+	if container == nil {
+		return nil
+	}
+	volume := ""
+	longest := -1
+	for _, m := range container.VolumeMounts {
+		path := strings.TrimSuffix(m.MountPath, "/")
+		if (s.Dir == path || strings.HasPrefix(s.Dir, path+"/") || path == "") && len(path) > longest {
+			volume = m.Name
+			longest = len(path)
+		}
+	}
+	for _, v := range r.pod.Spec.Volumes {
+		if v.Name != volume || v.PersistentVolumeClaim == nil {
+			continue
+		}
+		s.PVC = v.PersistentVolumeClaim.ClaimName
+		pvc, err := r.clientset.CoreV1().PersistentVolumeClaims(r.pod.Namespace).Get(ctx, s.PVC, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if q, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+			s.PVCCapacity = q.Value()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,423 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ClusterSpec is the desired topology and config of a redis cluster
+type ClusterSpec struct {
+	Masters []MasterSpec `json:"masters"`
+	// Config is applied to every pod in spec with config set
+	Config map[string]string `json:"config,omitempty"`
+	// Threshold is the slots difference percentage tolerated before reshard, default 2
+	Threshold int `json:"threshold,omitempty"`
+}
+
+type MasterSpec struct {
+	Pod string `json:"pod"`
+	// Weight of slots assigned to this master, default 1
+	Weight   *int     `json:"weight,omitempty"`
+	Replicas []string `json:"replicas,omitempty"`
+}
+
+func (m *MasterSpec) weight() int {
+	if m.Weight == nil {
+		return 1
+	}
+	return *m.Weight
+}
+
+// Validate checks spec and fills defaults
+func (s *ClusterSpec) Validate() error {
+	if len(s.Masters) == 0 {
+		return errors.New("no master in spec")
+	}
+	if s.Threshold == 0 {
+		s.Threshold = 2
+	}
+	if s.Threshold < 0 {
+		return errors.New("threshold should > 0")
+	}
+	seen := make(map[string]bool)
+	total := 0
+	for _, m := range s.Masters {
+		if m.weight() < 0 {
+			return fmt.Errorf("weight of %s should >= 0", m.Pod)
+		}
+		total += m.weight()
+		for _, p := range append([]string{m.Pod}, m.Replicas...) {
+			if p == "" {
+				return errors.New("empty pod name in spec")
+			}
+			if seen[p] {
+				return fmt.Errorf("pod %s appears more than once in spec", p)
+			}
+			seen[p] = true
+		}
+	}
+	if total == 0 {
+		return errors.New("at least one master should have weight > 0")
+	}
+	return nil
+}
+
+// Pods returns all pods in spec, masters first
+func (s *ClusterSpec) Pods() []string {
+	pods := make([]string, 0)
+	for _, m := range s.Masters {
+		pods = append(pods, m.Pod)
+	}
+	for _, m := range s.Masters {
+		pods = append(pods, m.Replicas...)
+	}
+	return pods
+}
+
+const (
+	ActionAddNode   = "add-node"
+	ActionFailover  = "failover"
+	ActionReshard   = "reshard"
+	ActionReplicate = "replicate"
+	ActionConfigSet = "config-set"
+	ActionForget    = "forget"
+)
+
+// PlanAction is a single step to move cluster towards spec
+type PlanAction struct {
+	Type string
+	Pod  string
+	// Target is master pod of add-node (as slave) and replicate
+	Target    string
+	Key       string
+	Value     string
+	Weights   map[string]string
+	Threshold int
+	Reason    string
+}
+
+func (a *PlanAction) String() string {
+	switch a.Type {
+	case ActionAddNode:
+		if a.Target != "" {
+			return fmt.Sprintf("add-node %s as slave of %s", a.Pod, a.Target)
+		}
+		return fmt.Sprintf("add-node %s as master", a.Pod)
+	case ActionFailover:
+		return fmt.Sprintf("failover %s to master", a.Pod)
+	case ActionReshard:
+		pods := make([]string, 0, len(a.Weights))
+		for p := range a.Weights {
+			pods = append(pods, p)
+		}
+		sort.Strings(pods)
+		s := "reshard with weights"
+		for _, p := range pods {
+			s += fmt.Sprintf(" %s=%s", p, a.Weights[p])
+		}
+		return s
+	case ActionReplicate:
+		return fmt.Sprintf("replicate %s to %s", a.Pod, a.Target)
+	case ActionConfigSet:
+		return fmt.Sprintf("config set %s %s on %s", a.Key, a.Value, a.Pod)
+	case ActionForget:
+		return fmt.Sprintf("forget %s", a.Pod)
+	}
+	return a.Type
+}
+
+// Plan compares spec with live cluster nodes seen by r, returns actions in the order they should be applied
+func (r *RedisPod) Plan(ctx context.Context, spec *ClusterSpec) ([]*PlanAction, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	nodes, err := r.ClusterNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	specPods := spec.Pods()
+	if len(nodes) == 1 && len(specPods) > 1 {
+		return nil, fmt.Errorf("%s doesn't know other nodes, use a pod in the cluster as entry", r.GetName())
+	}
+	byPod := make(map[string]*RedisNode)
+	byID := make(map[string]*RedisNode)
+	for _, n := range nodes {
+		byPod[n.Pod.Name] = n
+		byID[n.ID] = n
+	}
+	inSpec := make(map[string]bool)
+	for _, p := range specPods {
+		inSpec[p] = true
+	}
+	if !inSpec[r.GetName()] {
+		return nil, fmt.Errorf("entry pod %s is not in spec", r.GetName())
+	}
+	masterOf := func(n *RedisNode) string {
+		if m, ok := byID[n.MasterID]; ok {
+			return m.Pod.Name
+		}
+		return ""
+	}
+	actions := make([]*PlanAction, 0)
+
+	for _, m := range spec.Masters {
+		if _, ok := byPod[m.Pod]; !ok {
+			actions = append(actions, &PlanAction{Type: ActionAddNode, Pod: m.Pod, Reason: "not in cluster"})
+		}
+	}
+
+	// promoted maps a master demoted by failover to the slave replacing it
+	promoted := make(map[string]string)
+	for _, m := range spec.Masters {
+		n, ok := byPod[m.Pod]
+		if !ok || n.IsMaster() {
+			continue
+		}
+		if cur := masterOf(n); cur != "" {
+			promoted[cur] = m.Pod
+		}
+		actions = append(actions, &PlanAction{Type: ActionFailover, Pod: m.Pod, Reason: "is slave of " + masterOf(n)})
+	}
+
+	for _, m := range spec.Masters {
+		for _, p := range m.Replicas {
+			if _, ok := byPod[p]; !ok {
+				actions = append(actions, &PlanAction{Type: ActionAddNode, Pod: p, Target: m.Pod, Reason: "not in cluster"})
+			}
+		}
+	}
+
+	// slots owned by every master after failover
+	owned := make(map[string]int)
+	masters := make(map[string]bool)
+	for _, n := range nodes {
+		if !n.IsMaster() {
+			continue
+		}
+		owner := n.Pod.Name
+		if p, ok := promoted[owner]; ok {
+			owner = p
+		}
+		masters[owner] = true
+		owned[owner] += n.SlotsCount()
+	}
+	total := 0
+	for _, m := range spec.Masters {
+		masters[m.Pod] = true
+		total += m.weight()
+	}
+	weights := make(map[string]string)
+	reshard := ""
+	for p := range masters {
+		weights[p] = "0"
+		if !inSpec[p] && owned[p] > 0 {
+			reshard = fmt.Sprintf("%s owns %d slots but is not in spec", p, owned[p])
+		}
+	}
+	for _, m := range spec.Masters {
+		if n, ok := byPod[m.Pod]; ok && !n.IsMaster() && masterOf(n) == "" {
+			// slave of unknown master, failover won't bring any slot
+			owned[m.Pod] = 0
+		}
+		weights[m.Pod] = strconv.Itoa(m.weight())
+		expected := SlotsNum * m.weight() / total
+		diff := owned[m.Pod] - expected
+		if diff < 0 {
+			diff = -diff
+		}
+		if (expected == 0 && diff > 0) || (expected > 0 && diff*100 > expected*spec.Threshold) {
+			reshard = fmt.Sprintf("%s owns %d slots, expects %d", m.Pod, owned[m.Pod], expected)
+		}
+	}
+	if reshard != "" {
+		actions = append(actions, &PlanAction{Type: ActionReshard, Weights: weights, Threshold: spec.Threshold, Reason: reshard})
+	}
+
+	for _, m := range spec.Masters {
+		for _, p := range m.Replicas {
+			n, ok := byPod[p]
+			if !ok {
+				continue
+			}
+			cur := ""
+			if n.IsMaster() {
+				cur = promoted[p]
+			} else {
+				cur = masterOf(n)
+				if np, ok := promoted[cur]; ok {
+					cur = np
+				}
+			}
+			if cur != m.Pod {
+				reason := "is master"
+				if cur != "" {
+					reason = "will be slave of " + cur
+				}
+				actions = append(actions, &PlanAction{Type: ActionReplicate, Pod: p, Target: m.Pod, Reason: reason})
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(spec.Config))
+	for k := range spec.Config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, p := range specPods {
+		if len(keys) == 0 {
+			break
+		}
+		pod, err := NewRedisPod(ctx, p, r.redisContainerName, r.pod.Namespace, r.port, r.conn, r.clientset, r.restcfg)
+		if err != nil {
+			return nil, err
+		}
+		for _, k := range keys {
+			v, err := pod.ConfigGetValue(ctx, k)
+			if err != nil {
+				return nil, err
+			}
+			if !configEqual(spec.Config[k], v) {
+				actions = append(actions, &PlanAction{Type: ActionConfigSet, Pod: p, Key: k, Value: spec.Config[k], Reason: "current value: " + v})
+			}
+		}
+	}
+
+	// forget slaves before masters, so no slave points to a forgotten master
+	for _, master := range []bool{false, true} {
+		for _, n := range nodes {
+			if !inSpec[n.Pod.Name] && n.IsMaster() == master {
+				actions = append(actions, &PlanAction{Type: ActionForget, Pod: n.Pod.Name, Reason: "not in spec"})
+			}
+		}
+	}
+	return actions, nil
+}
+
+// Apply executes a single plan action through r, waits until cluster is consistent after topology changes,
+// timeout is migrate timeout in milliseconds of a single batch during reshard.
+func (r *RedisPod) Apply(ctx context.Context, a *PlanAction, pipeline, timeout int, waitTimeout time.Duration) (string, error) {
+	newPod := func(name string) (*RedisPod, error) {
+		return NewRedisPod(ctx, name, r.redisContainerName, r.pod.Namespace, r.port, r.conn, r.clientset, r.restcfg)
+	}
+	nodeID := func(name string) (string, error) {
+		nodes, err := r.ClusterNodes(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, n := range nodes {
+			if n.Pod.Name == name {
+				return n.ID, nil
+			}
+		}
+		return "", fmt.Errorf("can't find pod %s in redis cluster nodes", name)
+	}
+	var res string
+	switch a.Type {
+	case ActionAddNode:
+		p, err := newPod(a.Pod)
+		if err != nil {
+			return "", err
+		}
+		entry := r
+		if a.Target != "" {
+			if entry, err = newPod(a.Target); err != nil {
+				return "", err
+			}
+		}
+		if res, err = entry.ClusterAddNode(ctx, p, a.Target != ""); err != nil {
+			return res, err
+		}
+	case ActionFailover:
+		p, err := newPod(a.Pod)
+		if err != nil {
+			return "", err
+		}
+		if res, err = p.ClusterFailover(ctx, false, false); err != nil {
+			return res, err
+		}
+		pollCtx, cancel := context.WithTimeout(ctx, waitTimeout)
+		defer cancel()
+		if err := wait.PollImmediateUntil(time.Second, func() (bool, error) {
+			return p.isMaster(ctx)
+		}, pollCtx.Done()); err != nil {
+			return res, fmt.Errorf("%s is not master after failover in %s: %v", a.Pod, waitTimeout, err)
+		}
+	case ActionReshard:
+		return r.ClusterRebalance(ctx, a.Weights, true, timeout, false, pipeline, a.Threshold, false)
+	case ActionReplicate:
+		p, err := newPod(a.Pod)
+		if err != nil {
+			return "", err
+		}
+		id, err := nodeID(a.Target)
+		if err != nil {
+			return "", err
+		}
+		if res, err = p.ClusterReplicate(ctx, id); err != nil {
+			return res, err
+		}
+	case ActionConfigSet:
+		p, err := newPod(a.Pod)
+		if err != nil {
+			return "", err
+		}
+		return p.ConfigSet(ctx, a.Key, a.Value)
+	case ActionForget:
+		id, err := nodeID(a.Pod)
+		if err != nil {
+			return "", err
+		}
+		if res, err = r.ClusterDelNode(ctx, id); err != nil {
+			return res, err
+		}
+	default:
+		return "", fmt.Errorf("unknown action %s", a.Type)
+	}
+	return res, r.WaitClusterConsistent(ctx, waitTimeout)
+}
+
+// memoryUnits are units of memory config values, as parsed by redis
+var memoryUnits = map[string]uint64{
+	"b": 1, "k": 1000, "kb": 1024, "m": 1000 * 1000, "mb": 1024 * 1024, "g": 1000 * 1000 * 1000, "gb": 1024 * 1024 * 1024,
+}
+
+// parseMemory parses config values like 1gb or 100m into bytes
+func parseMemory(v string) (uint64, bool) {
+	v = strings.ToLower(v)
+	i := strings.IndexFunc(v, func(r rune) bool { return r < '0' || r > '9' })
+	unit := uint64(1)
+	if i >= 0 {
+		u, ok := memoryUnits[v[i:]]
+		if !ok {
+			return 0, false
+		}
+		unit, v = u, v[:i]
+	}
+	n, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return n * unit, true
+}
+
+// configEqual compares config value in spec with the one read by config get, which redis normalizes:
+// memory units are converted to bytes, whitespace is collapsed, enums are lower case.
+func configEqual(spec, current string) bool {
+	spec, current = strings.Join(strings.Fields(spec), " "), strings.Join(strings.Fields(current), " ")
+	if strings.EqualFold(spec, current) {
+		return true
+	}
+	a, ok := parseMemory(spec)
+	if !ok {
+		return false
+	}
+	b, ok := parseMemory(current)
+	return ok && a == b
+}
@@ -0,0 +1,35 @@
+package redis
+
+import "testing"
+
+func TestConfigEqual(t *testing.T) {
+	tests := []struct {
+		spec    string
+		current string
+		equal   bool
+	}{
+		{"allkeys-lru", "allkeys-lru", true},
+		{"1gb", "1073741824", true},
+		{"1GB", "1073741824", true},
+		{"100mb", "104857600", true},
+		{"1g", "1000000000", true},
+		{"64kb", "65536", true},
+		{"1gb", "1000000000", false},
+		{"900 1", "900 1", true},
+		{"900  1 300 10", "900 1 300 10", true},
+		{" yes", "yes", true},
+		{"YES", "yes", true},
+		{"no", "yes", false},
+		{"100", "100", true},
+		{"100", "200", false},
+		{"1xb", "1", false},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec+"="+tt.current, func(t *testing.T) {
+			if got := configEqual(tt.spec, tt.current); got != tt.equal {
+				t.Errorf("configEqual(%q, %q) = %t, want %t", tt.spec, tt.current, got, tt.equal)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// PreflightWarn needs confirmation
+	PreflightWarn = "warn"
+	// PreflightBlock refuses the action unless forced
+	PreflightBlock = "block"
+)
+
+// PreflightIssue is an unsafe condition found before a destructive action
+type PreflightIssue struct {
+	Level string
+	Msg   string
+}
+
+func (i *PreflightIssue) String() string {
+	return fmt.Sprintf("[%s] %s", i.Level, i.Msg)
+}
+
+func warn(format string, a ...interface{}) *PreflightIssue {
+	return &PreflightIssue{Level: PreflightWarn, Msg: fmt.Sprintf(format, a...)}
+}
+
+func block(format string, a ...interface{}) *PreflightIssue {
+	return &PreflightIssue{Level: PreflightBlock, Msg: fmt.Sprintf(format, a...)}
+}
+
+// DelNodePreflight checks whether deleting node from the cluster r belongs to is safe
+func (r *RedisPod) DelNodePreflight(ctx context.Context, nodeID string) ([]*PreflightIssue, error) {
+	nodes, err := r.ClusterNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var target *RedisNode
+	byID := make(map[string]*RedisNode)
+	for _, n := range nodes {
+		byID[n.ID] = n
+		if n.ID == nodeID {
+			target = n
+		}
+	}
+	if target == nil {
+		return nil, fmt.Errorf("node %s is not in cluster", nodeID)
+	}
+	issues := make([]*PreflightIssue, 0)
+	if len(target.Migrating) > 0 || len(target.Importing) > 0 {
+		issues = append(issues, block("%s has %d open slots, run `rc slots` and fix them first", target.Pod.Name, len(target.Migrating)+len(target.Importing)))
+	}
+	if target.IsMaster() {
+		if c := target.SlotsCount(); c > 0 {
+			issues = append(issues, block("%s is master owning %d slots, reshard them away first", target.Pod.Name, c))
+		}
+		slaves := make([]string, 0)
+		for _, n := range nodes {
+			if n.MasterID == target.ID {
+				slaves = append(slaves, n.Pod.Name)
+			}
+		}
+		if len(slaves) > 0 {
+			issues = append(issues, warn("slaves %s will lose their master", strings.Join(slaves, ",")))
+		}
+		return issues, nil
+	}
+	master, ok := byID[target.MasterID]
+	if !ok {
+		return issues, nil
+	}
+	healthy := 0
+	for _, n := range nodes {
+		if n.ID != target.ID && n.MasterID == master.ID && !n.HasFlag("fail") && !n.HasFlag("fail?") {
+			healthy++
+		}
+	}
+	if healthy == 0 && master.SlotsCount() > 0 {
+		issues = append(issues, warn("%s is the last healthy replica of %s, the shard will have no failover candidate", target.Pod.Name, master.Pod.Name))
+	}
+	return issues, nil
+}
+
+// FailoverPreflight checks whether promoting slave r is safe, maxLag is replication offset lag in bytes
+func (r *RedisPod) FailoverPreflight(ctx context.Context, force, takeover bool, maxLag int64) ([]*PreflightIssue, error) {
+	nodes, err := r.ClusterNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	myID, err := r.GetNodeID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var me, master *RedisNode
+	for _, n := range nodes {
+		if n.ID == myID {
+			me = n
+		}
+	}
+	if me == nil || me.IsMaster() {
+		// ClusterFailover refuses masters
+		return nil, nil
+	}
+	for _, n := range nodes {
+		if n.ID == me.MasterID {
+			master = n
+		}
+	}
+	issues := make([]*PreflightIssue, 0)
+	info, err := r.Info(ctx, "replication")
+	if err != nil {
+		return nil, err
+	}
+	if info["master_link_status"] != "up" {
+		issues = append(issues, warn("replication link of %s is %s, down since %s seconds", r.GetName(), info["master_link_status"], info["master_link_down_since_seconds"]))
+	}
+	if master == nil {
+		return append(issues, warn("master %s of %s is unknown to cluster", me.MasterID, r.GetName())), nil
+	}
+
+	reachable := !master.HasFlag("fail") && !master.HasFlag("fail?")
+	var minfo map[string]string
+	if reachable {
+		m := NewRedisPodWithPod(master.Pod, r.redisContainerName, r.port, r.conn, r.clientset, r.restcfg)
+		if minfo, err = m.Info(ctx, "replication"); err != nil {
+			reachable = false
+		}
+	}
+	switch {
+	case takeover && reachable:
+		issues = append(issues, block("master %s is reachable, takeover skips cluster consensus and may cause split brain, use failover without --takeover", master.Pod.Name))
+	case force && reachable:
+		issues = append(issues, warn("master %s is reachable, force skips handshake and writes not replicated yet are lost", master.Pod.Name))
+	case !force && !takeover && !reachable:
+		issues = append(issues, warn("master %s is not reachable, failover without --force or --takeover won't succeed", master.Pod.Name))
+	}
+	if reachable {
+		moffset, _ := strconv.ParseInt(minfo["master_repl_offset"], 10, 64)
+		offset, _ := strconv.ParseInt(info["slave_repl_offset"], 10, 64)
+		if lag := moffset - offset; lag > maxLag {
+			if force || takeover {
+				issues = append(issues, block("%s is %d bytes behind master %s, they are lost without handshake", r.GetName(), lag, master.Pod.Name))
+			} else {
+				issues = append(issues, warn("%s is %d bytes behind master %s, failover waits until it catches up", r.GetName(), lag, master.Pod.Name))
+			}
+		}
+	}
+	return issues, nil
+}
+
+// ReassignSlaves picks a new master for every slave of a removed master on a remaining pod,
+// the remaining master with fewest slaves is picked each time. removed is pod names.
+func ReassignSlaves(nodes []*RedisNode, removed map[string]bool) map[*RedisNode]*RedisNode {
+	remaining := make([]*RedisNode, 0)
+	removedMasters := make(map[string]bool)
+	slaveCount := make(map[string]int)
+	for _, n := range nodes {
+		switch {
+		case n.IsMaster() && removed[n.Pod.Name]:
+			removedMasters[n.ID] = true
+		case n.IsMaster():
+			remaining = append(remaining, n)
+		case !removed[n.Pod.Name]:
+			slaveCount[n.MasterID]++
+		}
+	}
+	res := make(map[*RedisNode]*RedisNode)
+	if len(remaining) == 0 {
+		return res
+	}
+	for _, n := range nodes {
+		if n.IsMaster() || removed[n.Pod.Name] || !removedMasters[n.MasterID] {
+			continue
+		}
+		m := remaining[0]
+		for _, rm := range remaining[1:] {
+			if slaveCount[rm.ID] < slaveCount[m.ID] {
+				m = rm
+			}
+		}
+		res[n] = m
+		slaveCount[m.ID]++
+	}
+	return res
+}
+
+// ScaleInPreflight checks whether removing pods in removed (by name) from cluster is safe,
+// slaves of removed masters are expected to be moved by ReassignSlaves
+func ScaleInPreflight(nodes []*RedisNode, removed map[string]bool) []*PreflightIssue {
+	issues := make([]*PreflightIssue, 0)
+	replicas := make(map[string]int)
+	for _, n := range nodes {
+		if n.HasFlag("fail") || n.HasFlag("fail?") {
+			issues = append(issues, block("%s is failing, slots can't be moved safely, fix it first", n.Pod.Name))
+		}
+		if removed[n.Pod.Name] && (len(n.Migrating) > 0 || len(n.Importing) > 0) {
+			issues = append(issues, block("%s has %d open slots, run `rc slots` and fix them first", n.Pod.Name, len(n.Migrating)+len(n.Importing)))
+		}
+		if !n.IsMaster() && !removed[n.Pod.Name] {
+			replicas[n.MasterID]++
+		}
+	}
+	for _, m := range ReassignSlaves(nodes, removed) {
+		replicas[m.ID]++
+	}
+	for _, n := range nodes {
+		if !n.IsMaster() || removed[n.Pod.Name] {
+			continue
+		}
+		if replicas[n.ID] == 0 {
+			issues = append(issues, warn("master %s will have no replica after scale-in, it can't fail over", n.Pod.Name))
+		}
+	}
+	return issues
+}
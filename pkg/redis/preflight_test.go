@@ -0,0 +1,85 @@
+package redis
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// podNode is a node of pod name, masterID is "" for masters
+func podNode(name, masterID string, flags ...string) *RedisNode {
+	n := &RedisNode{ID: name + "-id", Pod: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: name}}, MasterID: masterID, Flags: flags}
+	if masterID == "" {
+		n.Flags = append(n.Flags, "master")
+		n.Slots = []SlotRange{{0, 100}}
+	} else {
+		n.Flags = append(n.Flags, "slave")
+	}
+	return n
+}
+
+func TestScaleInPreflight(t *testing.T) {
+	tests := []struct {
+		name    string
+		nodes   []*RedisNode
+		removed []string
+		// levels of issues in order
+		levels []string
+		// reassigned is slave pod -> new master pod
+		reassigned map[string]string
+	}{
+		{
+			name:    "removing a shard keeps replicas",
+			nodes:   []*RedisNode{podNode("rc-0", ""), podNode("rc-1", ""), podNode("rc-2", "rc-0-id"), podNode("rc-3", "rc-1-id")},
+			removed: []string{"rc-3", "rc-1"},
+		},
+		{
+			name:    "removing last replica warns",
+			nodes:   []*RedisNode{podNode("rc-0", ""), podNode("rc-1", ""), podNode("rc-2", "rc-0-id"), podNode("rc-3", "rc-1-id")},
+			removed: []string{"rc-3"},
+			levels:  []string{PreflightWarn},
+		},
+		{
+			name:       "slave of removed master is moved to master with fewest slaves",
+			nodes:      []*RedisNode{podNode("rc-0", ""), podNode("rc-1", ""), podNode("rc-2", ""), podNode("rc-3", "rc-0-id"), podNode("rc-4", "rc-2-id")},
+			removed:    []string{"rc-2"},
+			reassigned: map[string]string{"rc-4": "rc-1"},
+		},
+		{
+			name:    "failing node blocks",
+			nodes:   []*RedisNode{podNode("rc-0", ""), podNode("rc-1", "", "fail"), podNode("rc-2", "rc-0-id")},
+			removed: []string{"rc-1"},
+			levels:  []string{PreflightBlock},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			removed := make(map[string]bool)
+			for _, p := range tt.removed {
+				removed[p] = true
+			}
+			issues := ScaleInPreflight(tt.nodes, removed)
+			if len(issues) != len(tt.levels) {
+				t.Fatalf("got issues %v, want levels %v", issues, tt.levels)
+			}
+			for i, is := range issues {
+				if is.Level != tt.levels[i] {
+					t.Errorf("issue %d is %s, want %s", i, is, tt.levels[i])
+				}
+			}
+			reassigned := make(map[string]string)
+			for s, m := range ReassignSlaves(tt.nodes, removed) {
+				reassigned[s.Pod.Name] = m.Pod.Name
+			}
+			if len(reassigned) != len(tt.reassigned) {
+				t.Fatalf("got reassigned %v, want %v", reassigned, tt.reassigned)
+			}
+			for s, m := range tt.reassigned {
+				if reassigned[s] != m {
+					t.Errorf("%s is moved to %s, want %s", s, reassigned[s], m)
+				}
+			}
+		})
+	}
+}
@@ -6,9 +6,12 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
+	goredis "github.com/go-redis/redis/v8"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 
@@ -20,72 +23,143 @@ type RedisPod struct {
 	redisContainerName string
 	port               int
 	nodeID             string
+	conn               *common.ConnConfig
 	clientset          *kubernetes.Clientset
 	restcfg            *restclient.Config
 }
 
-func NewRedisPod(podname string, redisContainerName string, namespace string, port int, clientset *kubernetes.Clientset, restcfg *restclient.Config) (*RedisPod, error) {
-	pod, err := common.GetPod(podname, redisContainerName, namespace, clientset, restcfg)
+func NewRedisPod(ctx context.Context, podname string, redisContainerName string, namespace string, port int, conn *common.ConnConfig, clientset *kubernetes.Clientset, restcfg *restclient.Config) (*RedisPod, error) {
+	pod, err := common.GetPod(ctx, podname, redisContainerName, namespace, clientset, restcfg)
 	if err != nil {
 		return nil, err
 	}
-	return &RedisPod{pod: pod, redisContainerName: redisContainerName, port: port, clientset: clientset, restcfg: restcfg}, nil
+	return &RedisPod{pod: pod, redisContainerName: redisContainerName, port: port, conn: conn, clientset: clientset, restcfg: restcfg}, nil
 }
 
-func NewRedisPodWithPod(pod *corev1.Pod, redisContainerName string, port int, clientset *kubernetes.Clientset, restcfg *restclient.Config) *RedisPod {
-	return &RedisPod{pod: pod, redisContainerName: redisContainerName, port: port, clientset: clientset, restcfg: restcfg}
+func NewRedisPodWithPod(pod *corev1.Pod, redisContainerName string, port int, conn *common.ConnConfig, clientset *kubernetes.Clientset, restcfg *restclient.Config) *RedisPod {
+	return &RedisPod{pod: pod, redisContainerName: redisContainerName, port: port, conn: conn, clientset: clientset, restcfg: restcfg}
 }
 
 func (r *RedisPod) GetName() string {
 	return r.pod.Name
 }
 
+// ClusterName identifies cluster of r by statefulset owning the pod, pod name if it has none
+func (r *RedisPod) ClusterName() string {
+	for _, o := range r.pod.OwnerReferences {
+		if o.Kind == "StatefulSet" {
+			return o.Name
+		}
+	}
+	return r.pod.Name
+}
+
 func (r *RedisPod) GetIP() string {
 	return r.pod.Status.PodIP
 }
 
-func (r *RedisPod) ConfigGet(key string) (string, error) {
-	return r.redisCliLocal("config get "+key, false)
+func (r *RedisPod) ConfigGet(ctx context.Context, key string) (string, error) {
+	return r.redisCliLocal(ctx, "config get "+key, false)
 }
 
-func (r *RedisPod) Call(cmd ...string) (string, error) {
-	return r.redisCliLocal(strings.Join(cmd, " "), false)
+// ConfigGetValue returns value of a single config key
+func (r *RedisPod) ConfigGetValue(ctx context.Context, key string) (string, error) {
+	result, err := r.ConfigGet(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	reply, err := parseReply(result)
+	if err != nil {
+		return "", err
+	}
+	items, ok := reply.([]interface{})
+	if !ok || len(items) != 2 {
+		return "", fmt.Errorf("can't find config %s", key)
+	}
+	return replyString(items[1]), nil
 }
 
-func (r *RedisPod) ConfigSet(key, value string) (string, error) {
-	return r.redisCliLocal(fmt.Sprintf("config set %s %s", key, value), false)
+func (r *RedisPod) Call(ctx context.Context, cmd ...string) (string, error) {
+	return r.redisCliLocal(ctx, strings.Join(cmd, " "), false)
 }
 
-func (r *RedisPod) Ping() (string, error) {
-	return r.redisCliLocal("ping", false)
+// ConfigSet sets key to value, value is passed as a single argument, eg: save "900 1 300 10" or ""
+func (r *RedisPod) ConfigSet(ctx context.Context, key, value string) (string, error) {
+	return r.redisCliLocal(ctx, fmt.Sprintf("config set %s %s", common.ShellQuote(key), common.ShellQuote(value)), false)
 }
 
-func (r *RedisPod) GetNodeID() (nodeID string, err error) {
+func (r *RedisPod) Ping(ctx context.Context) (string, error) {
+	return r.redisCliLocal(ctx, "ping", false)
+}
+
+func (r *RedisPod) DBSize(ctx context.Context) (int64, error) {
+	result, err := r.redisCliLocal(ctx, "dbsize", true)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(strings.TrimSpace(result), 10, 64)
+}
+
+// NewClient returns go-redis client connected through port-forward, caller should stop the forwarder when done
+func (r *RedisPod) NewClient(ctx context.Context) (*goredis.Client, *common.PortForwarder, error) {
+	fw := common.NewPortForwarder(r.clientset, r.restcfg, r.pod, r.port, 0)
+	if err := fw.Start(ctx); err != nil {
+		return nil, nil, err
+	}
+	client := goredis.NewClient(r.conn.ApplyTo(&goredis.Options{Addr: fmt.Sprintf("localhost:%d", fw.LocalPort)}))
+	return client, fw, nil
+}
+
+func (r *RedisPod) GetNodeID(ctx context.Context) (nodeID string, err error) {
 	if r.nodeID != "" {
 		return r.nodeID, nil
 	}
-	nodeID, err = r.redisCliLocal("cluster myid", true)
+	nodeID, err = r.redisCliLocal(ctx, "cluster myid", true)
 	nodeID = strings.TrimSpace(nodeID)
 	r.nodeID = nodeID
 	return
 }
 
-func (r *RedisPod) isMaster() (bool, error) {
-	result, err := r.redisCliLocal("role", true)
+func (r *RedisPod) isMaster(ctx context.Context) (bool, error) {
+	result, err := r.redisCliLocal(ctx, "role", true)
 	if err != nil {
 		return false, err
 	}
-	if strings.Split(result, "\r\n")[0] == "master" {
+	if strings.TrimSpace(strings.Split(result, "\n")[0]) == "master" {
 		return true, nil
 	}
 	return false, nil
 }
 
-func (r *RedisPod) ClusterInfo() (string, error) {
-	return r.redisCliLocal("cluster info", false)
+// Info returns fields of info section, eg: replication
+func (r *RedisPod) Info(ctx context.Context, section string) (map[string]string, error) {
+	result, err := r.redisCliLocal(ctx, "info "+section, true)
+	if err != nil {
+		return nil, err
+	}
+	return parseInfo(result), nil
 }
 
-func (r *RedisPod) ClusterCreate(replicas int, yes bool, pods ...*RedisPod) (string, error) {
+// parseInfo parses "key:value" lines of info and cluster info
+func parseInfo(result string) map[string]string {
+	info := make(map[string]string)
+	for _, line := range strings.Split(result, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if i := strings.Index(line, ":"); i > 0 {
+			info[line[:i]] = line[i+1:]
+		}
+	}
+	return info
+}
+
+func (r *RedisPod) ClusterInfo(ctx context.Context) (string, error) {
+	return r.redisCliLocal(ctx, "cluster info", false)
+}
+
+func (r *RedisPod) ClusterCreate(ctx context.Context, replicas int, yes bool, pods ...*RedisPod) (string, error) {
 	l := make([]string, 1, len(pods)+1)
 	l[0] = fmt.Sprintf("%s:%d", r.GetIP(), r.port)
 	for _, p := range pods {
@@ -95,14 +169,14 @@ func (r *RedisPod) ClusterCreate(replicas int, yes bool, pods ...*RedisPod) (str
 	if yes {
 		cmd += " --cluster-yes"
 	}
-	return r.redisCliCluster(cmd, true, !yes)
+	return r.redisCliCluster(ctx, cmd, true, !yes)
 }
 
-func (r *RedisPod) ClusterFailover(force, takeover bool) (string, error) {
+func (r *RedisPod) ClusterFailover(ctx context.Context, force, takeover bool) (string, error) {
 	if force && takeover {
 		return "", errors.New("force and takeover can't be passed at sametime during failover")
 	}
-	isMaster, err := r.isMaster()
+	isMaster, err := r.isMaster(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -116,14 +190,14 @@ func (r *RedisPod) ClusterFailover(force, takeover bool) (string, error) {
 	if takeover {
 		cmd += " takeover"
 	}
-	return r.redisCliLocal(cmd, false)
+	return r.redisCliLocal(ctx, cmd, false)
 }
 
-func (r *RedisPod) ClusterRebalance(weights map[string]string, useEmptyMasters bool, timeout int, simulate bool, batch int, threshold int, replace bool) (string, error) {
+func (r *RedisPod) ClusterRebalance(ctx context.Context, weights map[string]string, useEmptyMasters bool, timeout int, simulate bool, batch int, threshold int, replace bool) (string, error) {
 	cmd := fmt.Sprintf("rebalance %s:%d", r.GetIP(), r.port)
 	if weights != nil && len(weights) > 0 {
 		nweights := make([]string, 0, len(weights))
-		nodes, err := r.ClusterNodes()
+		nodes, err := r.ClusterNodes(ctx)
 		if err != nil {
 			return "", err
 		}
@@ -161,11 +235,11 @@ func (r *RedisPod) ClusterRebalance(weights map[string]string, useEmptyMasters b
 	if replace {
 		cmd += " --cluster-replace"
 	}
-	return r.redisCliCluster(cmd, true, false)
+	return r.redisCliCluster(ctx, cmd, true, false)
 }
 
-func (r *RedisPod) clusterNodes() (nodes []*RedisNode, err error) {
-	result, err := r.redisCliLocal("cluster nodes", false)
+func (r *RedisPod) clusterNodes(ctx context.Context) (nodes []*RedisNode, err error) {
+	result, err := r.redisCliLocal(ctx, "cluster nodes", false)
 	if err != nil {
 		return nil, err
 	}
@@ -178,27 +252,27 @@ func (r *RedisPod) clusterNodes() (nodes []*RedisNode, err error) {
 }
 
 // ClusterNodes return redis nodes with pod info
-func (r *RedisPod) ClusterNodes() (nodes []*RedisNode, err error) {
-	m, err := r.getPodsInNamespace(r.pod.Namespace)
+func (r *RedisPod) ClusterNodes(ctx context.Context) (nodes []*RedisNode, err error) {
+	m, err := r.getPodsInNamespace(ctx, r.pod.Namespace)
 	if err != nil {
 		return nil, err
 	}
-	nodes, err = r.clusterNodes()
+	nodes, err = r.clusterNodes(ctx)
 	if err != nil {
 		return nil, err
 	}
 	for _, node := range nodes {
-		p, ok := m[node.IP]
+		p, ok := m.Find(node.IP, node.Hostname)
 		if !ok {
 			return nil, errors.New("can't find pod for ip " + node.IP)
 		}
-		node.Pod = &p
+		node.Pod = p
 	}
 	return
 }
 
-func (r *RedisPod) ClusterCheck() error {
-	result, err := r.redisCliCluster(fmt.Sprintf("check %s:%d", r.GetIP(), r.port), false, false)
+func (r *RedisPod) ClusterCheck(ctx context.Context) error {
+	result, err := r.redisCliCluster(ctx, fmt.Sprintf("check %s:%d", r.GetIP(), r.port), false, false)
 	if err != nil {
 		return err
 	}
@@ -206,141 +280,186 @@ func (r *RedisPod) ClusterCheck() error {
 	return nil
 }
 
-func (r *RedisPod) ClusterSlots() ([]*Slots, error) {
-	result, err := r.redisCliLocal("cluster slots", true)
+// https://redis.io/commands/cluster-slots
+func (r *RedisPod) ClusterSlots(ctx context.Context) ([]*Slots, error) {
+	result, err := r.redisCliLocal(ctx, "cluster slots", false)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := parseReply(result)
 	if err != nil {
 		return nil, err
 	}
-	result = strings.TrimSpace(result)
-	lines := strings.Split(result, "\r\n")
-	if len(lines) < 5 {
+	entries, ok := reply.([]interface{})
+	if !ok || len(entries) == 0 {
 		return nil, fmt.Errorf("wrong slots info %s", result)
 	}
-	m, err := r.getPodsInNamespace(r.pod.Namespace)
+	m, err := r.getPodsInNamespace(ctx, r.pod.Namespace)
 	if err != nil {
 		return nil, err
 	}
 
-	newPod := func (ip, portStr, nodeID string) (*RedisPod, error) {
-		p, ok := m[ip]
+	// node is [endpoint, port, id, metadata...], endpoint can be ip, hostname or "?",
+	// redis 7 puts ip and hostname in metadata
+	newPod := func(v interface{}) (*RedisPod, error) {
+		fields, ok := v.([]interface{})
+		if !ok || len(fields) < 3 {
+			return nil, fmt.Errorf("wrong slot node info %v", v)
+		}
+		endpoint := replyString(fields[0])
+		meta := make(map[string]interface{})
+		if len(fields) > 3 {
+			meta = replyMap(fields[3])
+		}
+		p, ok := m.Find(endpoint, replyString(meta["ip"]), replyString(meta["hostname"]))
 		if !ok {
-			return nil, fmt.Errorf("cant't find pod for ip %s", ip)
+			return nil, fmt.Errorf("cant't find pod for %s", endpoint)
 		}
-		port, err := strconv.Atoi(portStr)
+		port, err := replyInt(fields[1])
 		if err != nil {
 			return nil, err
 		}
-		pod := NewRedisPodWithPod(&p, "", port, r.clientset, r.restcfg)
-		pod.nodeID = nodeID
+		pod := NewRedisPodWithPod(p, "", int(port), r.conn, r.clientset, r.restcfg)
+		pod.nodeID = replyString(fields[2])
 		return pod, nil
 	}
 
-	slots := make([]*Slots, 0)
-	master, err := newPod(lines[2], lines[3], lines[4])
+	slots := make([]*Slots, 0, len(entries))
+	for _, e := range entries {
+		fields, ok := e.([]interface{})
+		if !ok || len(fields) < 3 {
+			return nil, fmt.Errorf("wrong slots info %v", e)
+		}
+		start, err := replyInt(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := replyInt(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		master, err := newPod(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		s := &Slots{Start: int(start), End: int(end), Master: master, Slaves: make([]*RedisPod, 0)}
+		for _, f := range fields[3:] {
+			slave, err := newPod(f)
+			if err != nil {
+				return nil, err
+			}
+			s.Slaves = append(s.Slaves, slave)
+		}
+		slots = append(slots, s)
+	}
+	return slots, nil
+}
+
+// ClusterShards is only supported by redis >= 7.0
+// https://redis.io/commands/cluster-shards
+func (r *RedisPod) ClusterShards(ctx context.Context) ([]*Shard, error) {
+	result, err := r.redisCliLocal(ctx, "cluster shards", false)
 	if err != nil {
 		return nil, err
 	}
-	start, err := strconv.Atoi(lines[0])
+	reply, err := parseReply(result)
 	if err != nil {
 		return nil, err
 	}
-	end, err := strconv.Atoi(lines[1])
+	entries, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("wrong shards info %s", result)
+	}
+	m, err := r.getPodsInNamespace(ctx, r.pod.Namespace)
 	if err != nil {
 		return nil, err
 	}
-	s := &Slots{Start: start, End: end, Master: master, Slaves: make([]*RedisPod, 0)}
-	slots = append(slots, s)
-	lines = lines[5:]
-	for {
-		if len(lines) == 0 {
-			break
-		}
-		if strings.Contains(lines[0], ".") {
-			// it's a slave node
-			last := slots[len(slots)-1]
-			slave, err := newPod(lines[0], lines[1], lines[2])
-			if err != nil {
-				return nil, err
-			}
-			last.Slaves = append(last.Slaves, slave)
-			lines = lines[3:]
-		} else {
-			// a new slot
-			master, err := newPod(lines[2], lines[3], lines[4])
+	shards := make([]*Shard, 0, len(entries))
+	for _, e := range entries {
+		info := replyMap(e)
+		shard := &Shard{Slots: make([]SlotRange, 0), Nodes: make([]*ShardNode, 0)}
+		bounds, _ := info["slots"].([]interface{})
+		for i := 0; i+1 < len(bounds); i += 2 {
+			start, err := replyInt(bounds[i])
 			if err != nil {
 				return nil, err
 			}
-			start, err := strconv.Atoi(lines[0])
+			end, err := replyInt(bounds[i+1])
 			if err != nil {
 				return nil, err
 			}
-			end, err := strconv.Atoi(lines[1])
-			if err != nil {
-				return nil, err
-			}
-			slots = append(slots, &Slots{Start: start, End: end, Master: master, Slaves: make([]*RedisPod, 0)})
-			lines = lines[5:]
+			shard.Slots = append(shard.Slots, SlotRange{Start: int(start), End: int(end)})
+		}
+		nodes, _ := info["nodes"].([]interface{})
+		for _, n := range nodes {
+			shard.Nodes = append(shard.Nodes, newShardNode(replyMap(n), m))
 		}
+		shards = append(shards, shard)
 	}
-	return slots, nil
+	return shards, nil
 }
 
-func (r *RedisPod) ClusterAddNode(newPod *RedisPod, slave bool) (result string, err error) {
+func (r *RedisPod) ClusterAddNode(ctx context.Context, newPod *RedisPod, slave bool) (result string, err error) {
 	cmd := fmt.Sprintf("add-node %s:%d %s:%d", newPod.GetIP(), r.port, r.GetIP(), r.port)
 	if slave {
-		isMaster, err := r.isMaster()
+		isMaster, err := r.isMaster(ctx)
 		if err != nil {
 			return "", err
 		}
 		if !isMaster {
 			return "", errors.New(fmt.Sprintf("%s is not master, can't add slave for it", r.pod.Name))
 		}
-		nodeID, err := r.GetNodeID()
+		nodeID, err := r.GetNodeID(ctx)
 		if err != nil {
 			return "", err
 		}
 		cmd = fmt.Sprintf("%s --cluster-slave --cluster-master-id %s", cmd, nodeID)
 	}
-	result, err = r.redisCliCluster(cmd, false, false)
+	result, err = r.redisCliCluster(ctx, cmd, false, false)
 	return
 }
 
-func (r *RedisPod) ClusterDelNode(nodeID string) (result string, err error) {
-	return r.redisCliCluster(fmt.Sprintf("del-node %s:%d %s", r.GetIP(), r.port, nodeID), false, false)
+func (r *RedisPod) ClusterDelNode(ctx context.Context, nodeID string) (result string, err error) {
+	return r.redisCliCluster(ctx, fmt.Sprintf("del-node %s:%d %s", r.GetIP(), r.port, nodeID), false, false)
 }
 
-func (r *RedisPod) redisCliCluster(cmd string, toStdout, toStdin bool) (string, error) {
-	return common.Execute(r.clientset, r.restcfg, &common.ExecTarget{Pod: r.pod, Container: r.redisContainerName}, fmt.Sprintf("redis-cli --cluster %s", cmd), toStdout, toStdin)
+// ClusterReplicate makes r slave of node
+func (r *RedisPod) ClusterReplicate(ctx context.Context, nodeID string) (string, error) {
+	return r.redisCliLocal(ctx, "cluster replicate "+nodeID, false)
 }
 
-func (r *RedisPod) redisCliLocal(cmd string, raw bool) (string, error) {
-	return r.redisCli(cmd, raw, "127.0.0.1", r.port)
+func (r *RedisPod) redisCliCluster(ctx context.Context, cmd string, toStdout, toStdin bool) (string, error) {
+	return common.Execute(ctx, r.clientset, r.restcfg, r.execTarget(), fmt.Sprintf("redis-cli %s --cluster %s", r.conn.CliArgs(), cmd), toStdout, toStdin)
 }
 
-func (r *RedisPod) redisCli(cmd string, raw bool, host string, port int) (string, error) {
+func (r *RedisPod) redisCliLocal(ctx context.Context, cmd string, raw bool) (string, error) {
+	return r.redisCli(ctx, cmd, raw, "127.0.0.1", r.port)
+}
+
+func (r *RedisPod) redisCli(ctx context.Context, cmd string, raw bool, host string, port int) (string, error) {
 	var c string
 	if raw {
-		c = fmt.Sprintf("redis-cli -c --raw -h %s -p %d %s ", host, port, cmd)
+		c = fmt.Sprintf("redis-cli -c --raw -h %s -p %d %s %s ", host, port, r.conn.CliArgs(), cmd)
 	} else {
-		c = fmt.Sprintf("redis-cli -c -h %s -p %d %s", host, port, cmd)
+		// force formatted output, exec may run without tty
+		c = fmt.Sprintf("redis-cli -c --no-raw -h %s -p %d %s %s", host, port, r.conn.CliArgs(), cmd)
 	}
-	return common.Execute(r.clientset, r.restcfg, &common.ExecTarget{Pod: r.pod, Container: r.redisContainerName}, c, false, false)
+	return common.Execute(ctx, r.clientset, r.restcfg, r.execTarget(), c, false, false)
 }
 
+func (r *RedisPod) execTarget() *common.ExecTarget {
+	return &common.ExecTarget{Pod: r.pod, Container: r.redisContainerName, Env: r.conn.CliEnv()}
+}
 
-func (s *RedisPod) getPodsInNamespace(namespace string) (map[string]corev1.Pod, error) {
-	pods, err := s.clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+func (s *RedisPod) getPodsInNamespace(ctx context.Context, namespace string) (common.PodIndex, error) {
+	pods, err := s.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
-	m := make(map[string]corev1.Pod)
-	for _, pod := range pods.Items {
-		m[pod.Status.PodIP] = pod
-	}
-	return m, nil
+	return common.NewPodIndex(pods.Items), nil
 }
 
-func (p *RedisPod) getPodsInStatefulSet() (map[string]corev1.Pod, error) {
+func (p *RedisPod) getPodsInStatefulSet(ctx context.Context) (map[string]corev1.Pod, error) {
 	stsName := ""
 	for _, r := range p.pod.OwnerReferences {
 		if *r.Controller && r.Kind == "StatefulSet" {
@@ -351,7 +470,7 @@ func (p *RedisPod) getPodsInStatefulSet() (map[string]corev1.Pod, error) {
 	if stsName == "" {
 		return nil, fmt.Errorf("pod %s is not managed by statefulset", p.pod.Name)
 	}
-	sts, err := p.clientset.AppsV1().StatefulSets(p.pod.Namespace).Get(context.Background(), stsName, metav1.GetOptions{})
+	sts, err := p.clientset.AppsV1().StatefulSets(p.pod.Namespace).Get(ctx, stsName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -359,7 +478,7 @@ func (p *RedisPod) getPodsInStatefulSet() (map[string]corev1.Pod, error) {
 	for k, v := range sts.Spec.Selector.MatchLabels {
 		selector = append(selector, fmt.Sprintf("%s=%s", k, v))
 	}
-	pods, err := p.clientset.CoreV1().Pods(p.pod.Namespace).List(context.Background(),
+	pods, err := p.clientset.CoreV1().Pods(p.pod.Namespace).List(ctx,
 		metav1.ListOptions{LabelSelector: strings.Join(selector, ",")})
 	if err != nil {
 		return nil, err
@@ -372,3 +491,38 @@ func (p *RedisPod) getPodsInStatefulSet() (map[string]corev1.Pod, error) {
 
 }
 
+// WaitClusterConsistent polls until all nodes in cluster know the same set of nodes
+func (r *RedisPod) WaitClusterConsistent(ctx context.Context, timeout time.Duration) error {
+	pollCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	err := wait.PollImmediateUntil(2*time.Second, func() (bool, error) {
+		nodes, err := r.ClusterNodes(ctx)
+		if err != nil {
+			return false, err
+		}
+		expected := make(map[string]bool)
+		for _, n := range nodes {
+			expected[n.ID] = true
+		}
+		for _, n := range nodes {
+			p := NewRedisPodWithPod(n.Pod, r.redisContainerName, r.port, r.conn, r.clientset, r.restcfg)
+			others, err := p.clusterNodes(ctx)
+			if err != nil {
+				return false, err
+			}
+			if len(others) != len(expected) {
+				return false, nil
+			}
+			for _, o := range others {
+				if !expected[o.ID] {
+					return false, nil
+				}
+			}
+		}
+		return true, nil
+	}, pollCtx.Done())
+	if err != nil {
+		return fmt.Errorf("cluster nodes are not consistent in %s: %v", timeout, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"k8s.io/klog/v2"
+)
+
+// Replication is INFO replication of a node, slave fields are empty on masters
+type Replication struct {
+	Role             string
+	MasterReplOffset int64
+	SlaveReplOffset  int64
+	MasterLinkStatus string
+	// LastIOSeconds is seconds since last interaction with master
+	LastIOSeconds  int64
+	SyncInProgress bool
+	// Lag is bytes behind master, -1 if master offset is unknown
+	Lag int64
+}
+
+func newReplication(info map[string]string) *Replication {
+	rep := &Replication{Role: info["role"], MasterLinkStatus: info["master_link_status"], Lag: -1}
+	rep.MasterReplOffset, _ = strconv.ParseInt(info["master_repl_offset"], 10, 64)
+	rep.SlaveReplOffset, _ = strconv.ParseInt(info["slave_repl_offset"], 10, 64)
+	rep.LastIOSeconds, _ = strconv.ParseInt(info["master_last_io_seconds_ago"], 10, 64)
+	rep.SyncInProgress = info["master_sync_in_progress"] == "1"
+	return rep
+}
+
+// IsSlave is true when role reported by node is slave
+func (rep *Replication) IsSlave() bool {
+	return rep.Role == "slave"
+}
+
+// Lagging is true for slaves behind master more than maxLag bytes, with link down or in full sync
+func (rep *Replication) Lagging(maxLag int64) bool {
+	return rep.IsSlave() && (rep.Lag > maxLag || rep.MasterLinkStatus != "up" || rep.SyncInProgress)
+}
+
+func (rep *Replication) String() string {
+	if !rep.IsSlave() {
+		return fmt.Sprintf("offset: %d", rep.MasterReplOffset)
+	}
+	lag := "?"
+	if rep.Lag >= 0 {
+		lag = strconv.FormatInt(rep.Lag, 10)
+	}
+	s := fmt.Sprintf("offset: %d, lag: %s, link: %s, last io: %ds", rep.SlaveReplOffset, lag, rep.MasterLinkStatus, rep.LastIOSeconds)
+	if rep.SyncInProgress {
+		s += ", syncing"
+	}
+	return s
+}
+
+// LoadReplication fills Replication of nodes from INFO replication of their pods,
+// lag of slaves is computed against offset of their masters. Unreachable nodes are left nil.
+func (r *RedisPod) LoadReplication(ctx context.Context, nodes []*RedisNode) {
+	sem := make(chan struct{}, probeConcurrency)
+	var wg sync.WaitGroup
+	for _, n := range nodes {
+		if n.Pod == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(n *RedisNode) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			p := NewRedisPodWithPod(n.Pod, r.redisContainerName, r.port, r.conn, r.clientset, r.restcfg)
+			info, err := p.Info(ctx, "replication")
+			if err != nil {
+				klog.V(2).Infof("failed to get replication info of %s: %v", n.Pod.Name, err)
+				return
+			}
+			n.Replication = newReplication(info)
+		}(n)
+	}
+	wg.Wait()
+
+	byID := make(map[string]*RedisNode)
+	for _, n := range nodes {
+		byID[n.ID] = n
+	}
+	for _, n := range nodes {
+		if n.Replication == nil || !n.Replication.IsSlave() {
+			continue
+		}
+		if m, ok := byID[n.MasterID]; ok && m.Replication != nil {
+			n.Replication.Lag = m.Replication.MasterReplOffset - n.Replication.SlaveReplOffset
+		}
+	}
+}
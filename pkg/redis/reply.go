@@ -0,0 +1,152 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseReply parses the formatted (non raw) output of redis-cli back to nested values,
+// arrays become []interface{}, scalars are string, int64 or nil, error replies become error.
+// Unlike --raw output, nesting is kept, so extra fields added by newer redis versions
+// (eg: hostname metadata in cluster slots) don't shift the result.
+func parseReply(output string) (interface{}, error) {
+	lines := make([]string, 0)
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("empty reply")
+	}
+	if _, _, ok := parseReplyMarker(lines[0], 0); !ok {
+		v := parseReplyScalar(strings.TrimSpace(strings.Join(lines, "\n")))
+		if err, ok := v.(error); ok {
+			return nil, err
+		}
+		return v, nil
+	}
+
+	type frame struct {
+		col   int
+		items *[]interface{}
+	}
+	rootItems := make([]interface{}, 0)
+	stack := make([]frame, 0)
+	for _, line := range lines {
+		col, pos, ok := parseReplyMarker(line, 0)
+		if !ok {
+			return nil, fmt.Errorf("unexpected line in reply: %q", line)
+		}
+		if len(stack) == 0 {
+			stack = append(stack, frame{col: col, items: &rootItems})
+		}
+		for len(stack) > 1 && stack[len(stack)-1].col > col {
+			stack = stack[:len(stack)-1]
+		}
+		if stack[len(stack)-1].col != col {
+			return nil, fmt.Errorf("bad indent in reply: %q", line)
+		}
+		for {
+			top := stack[len(stack)-1]
+			nextCol, nextPos, nested := parseReplyMarker(line, pos)
+			if !nested {
+				*top.items = append(*top.items, parseReplyScalar(line[pos:]))
+				break
+			}
+			// first element of a nested array starts on the same line
+			items := make([]interface{}, 0)
+			*top.items = append(*top.items, &items)
+			stack = append(stack, frame{col: nextCol, items: &items})
+			pos = nextPos
+		}
+	}
+	return unwrapReply(rootItems), nil
+}
+
+// parseReplyMarker finds array index marker like " 1) " from pos, returns column of ')'
+// and start position of the element.
+func parseReplyMarker(line string, pos int) (col int, next int, ok bool) {
+	i := pos
+	for i < len(line) && line[i] == ' ' {
+		i++
+	}
+	start := i
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	if i == start || i+1 >= len(line) || line[i+1] != ' ' {
+		return 0, 0, false
+	}
+	if line[i] != ')' && line[i] != '~' && line[i] != '#' {
+		return 0, 0, false
+	}
+	return i, i + 2, true
+}
+
+func parseReplyScalar(s string) interface{} {
+	switch {
+	case s == "(nil)":
+		return nil
+	case s == "(empty array)" || s == "(empty list or set)":
+		return []interface{}{}
+	case strings.HasPrefix(s, "(integer) "):
+		if n, err := strconv.ParseInt(strings.TrimPrefix(s, "(integer) "), 10, 64); err == nil {
+			return n
+		}
+	case strings.HasPrefix(s, "(double) "):
+		return strings.TrimPrefix(s, "(double) ")
+	case strings.HasPrefix(s, "(error) "):
+		return errors.New(strings.TrimPrefix(s, "(error) "))
+	case strings.HasPrefix(s, `"`):
+		if v, err := strconv.Unquote(s); err == nil {
+			return v
+		}
+	}
+	return s
+}
+
+func unwrapReply(items []interface{}) []interface{} {
+	for i, item := range items {
+		if nested, ok := item.(*[]interface{}); ok {
+			items[i] = unwrapReply(*nested)
+		}
+	}
+	return items
+}
+
+func replyString(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case int64:
+		return strconv.FormatInt(val, 10)
+	}
+	return ""
+}
+
+func replyInt(v interface{}) (int64, error) {
+	switch val := v.(type) {
+	case int64:
+		return val, nil
+	case string:
+		return strconv.ParseInt(val, 10, 64)
+	}
+	return 0, fmt.Errorf("can't convert %v to int", v)
+}
+
+// replyMap converts a flat key/value array reply to map
+func replyMap(v interface{}) map[string]interface{} {
+	m := make(map[string]interface{})
+	items, ok := v.([]interface{})
+	if !ok {
+		return m
+	}
+	for i := 0; i+1 < len(items); i += 2 {
+		m[replyString(items[i])] = items[i+1]
+	}
+	return m
+}
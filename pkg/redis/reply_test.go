@@ -0,0 +1,86 @@
+package redis
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseReply(t *testing.T) {
+	twelve := make([]interface{}, 12)
+	for i := range twelve {
+		twelve[i] = fmt.Sprintf("v%d", i+1)
+	}
+	tests := []struct {
+		name   string
+		output string
+		want   interface{}
+	}{
+		{"string", `"bar"`, "bar"},
+		{"quoted string", `"a \"b\"\n"`, "a \"b\"\n"},
+		{"integer", "(integer) 42", int64(42)},
+		{"nil", "(nil)", nil},
+		{"status", "OK", "OK"},
+		{"empty array", "(empty array)", []interface{}{}},
+		{"empty list of old redis-cli", "(empty list or set)", []interface{}{}},
+		{"flat array", "1) \"a\"\n2) (integer) 1\n3) (nil)\n", []interface{}{"a", int64(1), nil}},
+		{
+			"nested array",
+			"1) 1) (integer) 0\n   2) (integer) 5460\n   3) 1) \"10.0.0.1\"\n      2) (integer) 6379\n2) \"x\"\n",
+			[]interface{}{[]interface{}{int64(0), int64(5460), []interface{}{"10.0.0.1", int64(6379)}}, "x"},
+		},
+		{
+			"array of 12 elements has wider index",
+			" 1) \"v1\"\n 2) \"v2\"\n 3) \"v3\"\n 4) \"v4\"\n 5) \"v5\"\n 6) \"v6\"\n 7) \"v7\"\n 8) \"v8\"\n 9) \"v9\"\n10) \"v10\"\n11) \"v11\"\n12) \"v12\"\n",
+			twelve,
+		},
+		{
+			"nested arrays in wide array",
+			" 1) 1) \"a\"\n    2) \"b\"\n 2) (empty array)\n 3) \"c\"\n 4) \"d\"\n 5) \"e\"\n 6) \"f\"\n 7) \"g\"\n 8) \"h\"\n 9) \"i\"\n10) 1) \"j\"\n    2) 1) (integer) 1\n",
+			[]interface{}{[]interface{}{"a", "b"}, []interface{}{}, "c", "d", "e", "f", "g", "h", "i", []interface{}{"j", []interface{}{int64(1)}}},
+		},
+		{"error inside array", "1) (error) ERR x\n", []interface{}{errors.New("ERR x")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseReply(tt.output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseReplyError(t *testing.T) {
+	if _, err := parseReply("(error) ERR unknown command"); err == nil || err.Error() != "ERR unknown command" {
+		t.Errorf("got %v, want ERR unknown command", err)
+	}
+	if _, err := parseReply("\n"); err == nil {
+		t.Error("empty output should fail")
+	}
+}
+
+// FormatReply mimics redis-cli, its output must parse back to the same value
+func TestParseReplyRoundTrip(t *testing.T) {
+	wide := make([]interface{}, 0, 15)
+	for i := 0; i < 15; i++ {
+		wide = append(wide, []interface{}{int64(i), fmt.Sprintf("n%d", i), []interface{}{}})
+	}
+	values := []interface{}{
+		[]interface{}{"a", []interface{}{"b", []interface{}{"c", int64(3)}}, nil},
+		wide,
+	}
+	for _, v := range values {
+		got, err := parseReply(FormatReply(v))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, v) {
+			t.Errorf("got %#v, want %#v", got, v)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	goredis "github.com/go-redis/redis/v8"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+)
+
+// maxRedirects bounds MOVED/ASK redirects followed for a single command
+const maxRedirects = 5
+
+// commands which switch connection into a streaming mode
+var sessionUnsupported = map[string]bool{"subscribe": true, "psubscribe": true, "ssubscribe": true, "monitor": true, "sync": true, "psync": true}
+
+type sessionConn struct {
+	client *goredis.Client
+	conn   *goredis.Conn
+	fw     *common.PortForwarder
+}
+
+// ClusterSession routes commands by key slot to the owning pods through port-forwards,
+// keeping a dedicated connection per pod, so connection state survives between commands.
+type ClusterSession struct {
+	entry    *RedisPod
+	readonly bool
+	pods     common.PodIndex
+	owners   [SlotsNum]*Slots
+	conns    map[string]*sessionConn
+	byName   map[string]*RedisPod
+	// Current handles commands without keys
+	Current string
+	// multi pins commands to a pod between MULTI and EXEC/DISCARD
+	multi string
+	// writes caches whether a command writes, by lower case name
+	writes map[string]bool
+}
+
+// NewClusterSession creates session entering cluster from entry, readonly routes keyed commands to slaves
+func NewClusterSession(ctx context.Context, entry *RedisPod, readonly bool) (*ClusterSession, error) {
+	s := &ClusterSession{entry: entry, readonly: readonly, conns: make(map[string]*sessionConn), writes: make(map[string]bool),
+		byName: map[string]*RedisPod{entry.GetName(): entry}, Current: entry.GetName()}
+	if err := s.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Refresh reloads slot owners and pods
+func (s *ClusterSession) Refresh(ctx context.Context) error {
+	pods, err := s.entry.getPodsInNamespace(ctx, s.entry.pod.Namespace)
+	if err != nil {
+		return err
+	}
+	slots, err := s.entry.ClusterSlots(ctx)
+	if err != nil {
+		return err
+	}
+	s.pods = pods
+	s.owners = [SlotsNum]*Slots{}
+	for _, sl := range slots {
+		s.byName[sl.Master.GetName()] = sl.Master
+		for _, p := range sl.Slaves {
+			s.byName[p.GetName()] = p
+		}
+		for i := sl.Start; i <= sl.End; i++ {
+			s.owners[i] = sl
+		}
+	}
+	return nil
+}
+
+// Use makes pod the target of commands without keys
+func (s *ClusterSession) Use(ctx context.Context, name string) error {
+	if s.multi != "" {
+		return errors.New("can't switch pod inside MULTI")
+	}
+	if _, err := s.conn(ctx, name); err != nil {
+		return err
+	}
+	s.Current = name
+	return nil
+}
+
+// Close closes all connections and port-forwards
+func (s *ClusterSession) Close() {
+	for _, c := range s.conns {
+		c.conn.Close()
+		c.client.Close()
+		c.fw.Stop()
+	}
+	s.conns = make(map[string]*sessionConn)
+}
+
+func (s *ClusterSession) conn(ctx context.Context, name string) (*sessionConn, error) {
+	if c, ok := s.conns[name]; ok {
+		return c, nil
+	}
+	p, ok := s.byName[name]
+	if !ok {
+		pod, ok := s.pods.Find(name)
+		if !ok {
+			return nil, fmt.Errorf("can't find pod %s", name)
+		}
+		p = NewRedisPodWithPod(pod, "", s.entry.port, s.entry.conn, s.entry.clientset, s.entry.restcfg)
+		s.byName[name] = p
+	}
+	client, fw, err := p.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := &sessionConn{client: client, conn: client.Conn(ctx), fw: fw}
+	if s.readonly {
+		// allows reads on slaves, no-op on masters
+		if err := c.conn.ReadOnly(ctx).Err(); err != nil {
+			c.conn.Close()
+			client.Close()
+			fw.Stop()
+			return nil, err
+		}
+	}
+	s.conns[name] = c
+	return c, nil
+}
+
+// route returns pod owning the keys of args, or current pod when command has no key
+func (s *ClusterSession) route(ctx context.Context, args []string) string {
+	if len(args) < 2 {
+		return s.Current
+	}
+	c, err := s.conn(ctx, s.Current)
+	if err != nil {
+		return s.Current
+	}
+	getkeys := append([]interface{}{"command", "getkeys"}, toInterfaces(args)...)
+	v, err := connDo(ctx, c.conn, getkeys...).Result()
+	keys, ok := v.([]interface{})
+	if err != nil || !ok || len(keys) == 0 {
+		return s.Current
+	}
+	owner := s.owners[KeySlot(fmt.Sprint(keys[0]))]
+	if owner == nil {
+		return s.Current
+	}
+	if s.readonly && len(owner.Slaves) > 0 && !s.isWrite(ctx, c.conn, args[0]) {
+		return owner.Slaves[0].GetName()
+	}
+	return owner.Master.GetName()
+}
+
+// isWrite tells whether command has the write flag in COMMAND INFO, unknown commands are treated as reads
+func (s *ClusterSession) isWrite(ctx context.Context, conn *goredis.Conn, name string) bool {
+	name = strings.ToLower(name)
+	if w, ok := s.writes[name]; ok {
+		return w
+	}
+	// reply is [[name, arity, [flags...], ...]]
+	v, err := connDo(ctx, conn, "command", "info", name).Result()
+	if err != nil {
+		return false
+	}
+	write := false
+	if infos, ok := v.([]interface{}); ok && len(infos) == 1 {
+		if info, ok := infos[0].([]interface{}); ok && len(info) > 2 {
+			if flags, ok := info[2].([]interface{}); ok {
+				for _, f := range flags {
+					if fmt.Sprint(f) == "write" {
+						write = true
+					}
+				}
+			}
+		}
+	}
+	s.writes[name] = write
+	return write
+}
+
+// Do runs args in cluster, returns reply and pods visited, last one answered
+func (s *ClusterSession) Do(ctx context.Context, args ...string) (interface{}, []string, error) {
+	if len(args) == 0 {
+		return nil, nil, errors.New("empty command")
+	}
+	name := strings.ToLower(args[0])
+	if sessionUnsupported[name] {
+		return nil, nil, fmt.Errorf("%s is not supported in cli", name)
+	}
+	target := s.multi
+	if target == "" {
+		target = s.route(ctx, args)
+	}
+	hops := []string{target}
+	asking := false
+	for i := 0; ; i++ {
+		c, err := s.conn(ctx, target)
+		if err != nil {
+			return nil, hops, err
+		}
+		if asking {
+			if err := connDo(ctx, c.conn, "asking").Err(); err != nil {
+				return nil, hops, err
+			}
+		}
+		v, err := connDo(ctx, c.conn, toInterfaces(args)...).Result()
+		if err == goredis.Nil {
+			err = nil
+		}
+		if err != nil && s.multi == "" && i < maxRedirects {
+			if kind, slot, addr, ok := parseRedirect(err.Error()); ok {
+				next, rerr := s.resolve(addr)
+				if rerr != nil {
+					return nil, hops, rerr
+				}
+				// a slave redirecting to the known owner doesn't mean topology changed
+				if kind == "MOVED" && !s.isOwner(slot, next) {
+					if rerr := s.Refresh(ctx); rerr != nil {
+						return nil, hops, rerr
+					}
+				}
+				asking = kind == "ASK"
+				target = next
+				hops = append(hops, kind, target)
+				continue
+			}
+		}
+		switch {
+		case name == "multi" && err == nil:
+			s.multi = target
+		case name == "exec" || name == "discard":
+			s.multi = ""
+		}
+		return v, hops, err
+	}
+}
+
+// resolve returns pod name of redirect address
+func (s *ClusterSession) resolve(addr string) (string, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", err
+	}
+	pod, ok := s.pods.Find(host)
+	if !ok {
+		return "", fmt.Errorf("can't find pod for %s", addr)
+	}
+	if _, ok := s.byName[pod.Name]; !ok {
+		p, err := strconv.Atoi(port)
+		if err != nil {
+			return "", err
+		}
+		s.byName[pod.Name] = NewRedisPodWithPod(pod, "", p, s.entry.conn, s.entry.clientset, s.entry.restcfg)
+	}
+	return pod.Name, nil
+}
+
+// isOwner tells whether pod is the known master of slot
+func (s *ClusterSession) isOwner(slot int, pod string) bool {
+	if slot < 0 || slot >= SlotsNum || s.owners[slot] == nil {
+		return false
+	}
+	return s.owners[slot].Master.GetName() == pod
+}
+
+// parseRedirect parses "MOVED <slot> <addr>" and "ASK <slot> <addr>" errors
+func parseRedirect(msg string) (kind string, slot int, addr string, ok bool) {
+	fields := strings.Fields(msg)
+	if len(fields) != 3 || (fields[0] != "MOVED" && fields[0] != "ASK") {
+		return "", 0, "", false
+	}
+	slot, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, "", false
+	}
+	return fields[0], slot, fields[2], true
+}
+
+// connDo runs a generic command on conn, goredis.Conn has no Do in this version
+func connDo(ctx context.Context, conn *goredis.Conn, args ...interface{}) *goredis.Cmd {
+	cmd := goredis.NewCmd(ctx, args...)
+	_ = conn.Process(ctx, cmd)
+	return cmd
+}
+
+func toInterfaces(args []string) []interface{} {
+	res := make([]interface{}, len(args))
+	for i, a := range args {
+		res[i] = a
+	}
+	return res
+}
+
+// FormatReply formats reply like redis-cli
+func FormatReply(v interface{}) string {
+	return formatReply(v, "")
+}
+
+func formatReply(v interface{}, indent string) string {
+	switch r := v.(type) {
+	case nil:
+		return "(nil)"
+	case int64:
+		return fmt.Sprintf("(integer) %d", r)
+	case string:
+		return strconv.Quote(r)
+	case []interface{}:
+		if len(r) == 0 {
+			return "(empty array)"
+		}
+		width := len(strconv.Itoa(len(r)))
+		lines := make([]string, 0, len(r))
+		for i, item := range r {
+			prefix := fmt.Sprintf("%*d) ", width, i+1)
+			sub := formatReply(item, indent+strings.Repeat(" ", len(prefix)))
+			if i == 0 {
+				lines = append(lines, prefix+sub)
+			} else {
+				lines = append(lines, indent+prefix+sub)
+			}
+		}
+		return strings.Join(lines, "\n")
+	case error:
+		return "(error) " + r.Error()
+	}
+	return fmt.Sprint(v)
+}
+
+// SplitArgs splits a command line like redis-cli, supporting "double quotes" with escapes and 'single quotes'
+func SplitArgs(line string) ([]string, error) {
+	args := make([]string, 0)
+	i := 0
+	for {
+		for i < len(line) && (line[i] == ' ' || line[i] == '\t') {
+			i++
+		}
+		if i >= len(line) {
+			return args, nil
+		}
+		var cur strings.Builder
+		inq, insq := false, false
+		for ; i < len(line); i++ {
+			c := line[i]
+			if inq {
+				if c == '\\' && i+1 < len(line) {
+					i++
+					switch line[i] {
+					case 'n':
+						cur.WriteByte('\n')
+					case 'r':
+						cur.WriteByte('\r')
+					case 't':
+						cur.WriteByte('\t')
+					case 'x':
+						if i+2 < len(line) {
+							if b, err := strconv.ParseUint(line[i+1:i+3], 16, 8); err == nil {
+								cur.WriteByte(byte(b))
+								i += 2
+								continue
+							}
+						}
+						cur.WriteByte('x')
+					default:
+						cur.WriteByte(line[i])
+					}
+				} else if c == '"' {
+					inq = false
+				} else {
+					cur.WriteByte(c)
+				}
+			} else if insq {
+				if c == '\\' && i+1 < len(line) && line[i+1] == '\'' {
+					i++
+					cur.WriteByte('\'')
+				} else if c == '\'' {
+					insq = false
+				} else {
+					cur.WriteByte(c)
+				}
+			} else if c == ' ' || c == '\t' {
+				break
+			} else if c == '"' {
+				inq = true
+			} else if c == '\'' {
+				insq = true
+			} else {
+				cur.WriteByte(c)
+			}
+		}
+		if inq || insq {
+			return nil, errors.New("unbalanced quotes")
+		}
+		args = append(args, cur.String())
+	}
+}
@@ -0,0 +1,143 @@
+package redis
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"sort"
+)
+
+// SlotMap is owner of every slot, seen by one node
+type SlotMap struct {
+	// Owners is nil for uncovered slots
+	Owners [SlotsNum]*RedisNode
+	Open   map[int]*OpenSlot
+	// Masters are all masters sorted by pod name
+	Masters []*RedisNode
+}
+
+func NewSlotMap(nodes []*RedisNode) *SlotMap {
+	m := &SlotMap{Open: make(map[int]*OpenSlot)}
+	for _, n := range nodes {
+		if !n.IsMaster() {
+			continue
+		}
+		m.Masters = append(m.Masters, n)
+		for _, s := range n.Slots {
+			for i := s.Start; i <= s.End; i++ {
+				m.Owners[i] = n
+			}
+		}
+	}
+	sort.Slice(m.Masters, func(i, j int) bool { return m.Masters[i].Pod.Name < m.Masters[j].Pod.Name })
+	for _, s := range OpenSlots(nodes) {
+		m.Open[s.Slot] = s
+	}
+	return m
+}
+
+// Uncovered returns count of slots without owner
+func (m *SlotMap) Uncovered() int {
+	count := 0
+	for _, o := range m.Owners {
+		if o == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// Fragments returns count of contiguous slot ranges owned by n
+func (m *SlotMap) Fragments(n *RedisNode) int {
+	count := 0
+	for i, o := range m.Owners {
+		if o == n && (i == 0 || m.Owners[i-1] != n) {
+			count++
+		}
+	}
+	return count
+}
+
+// svgCols is slots per row in svg, 128 rows in total
+const svgCols = 128
+
+// masterColor returns a distinct hsl color of i-th master, golden angle keeps neighbours apart
+func masterColor(i int) string {
+	return fmt.Sprintf("hsl(%d,65%%,55%%)", i*137%360)
+}
+
+// WriteSVG renders slots as a 128x128 grid of cells of cellSize pixels, coloured by owner,
+// open slots are outlined and uncovered slots are left white with red border
+func (m *SlotMap) WriteSVG(w io.Writer, cellSize int) error {
+	colors := make(map[*RedisNode]string)
+	for i, n := range m.Masters {
+		colors[n] = masterColor(i)
+	}
+	size := svgCols * cellSize
+	legendY := size + 20
+	height := legendY + 20*len(m.Masters) + 20
+	if _, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="12">`+"\n", size, height); err != nil {
+		return err
+	}
+	rows := SlotsNum / svgCols
+	for r := 0; r < rows; r++ {
+		// consecutive slots of same owner in a row are merged into one rect
+		for start := r * svgCols; start < (r+1)*svgCols; {
+			end := start
+			for end+1 < (r+1)*svgCols && m.Owners[end+1] == m.Owners[start] {
+				end++
+			}
+			fill, stroke, title := "#fff", ` stroke="red"`, "uncovered"
+			if o := m.Owners[start]; o != nil {
+				fill, stroke, title = colors[o], "", o.Pod.Name
+			}
+			fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"%s><title>%d-%d %s</title></rect>`+"\n",
+				(start%svgCols)*cellSize, r*cellSize, (end-start+1)*cellSize, cellSize, fill, stroke, start, end, html.EscapeString(title))
+			start = end + 1
+		}
+	}
+	open := make([]int, 0, len(m.Open))
+	for slot := range m.Open {
+		open = append(open, slot)
+	}
+	sort.Ints(open)
+	for _, slot := range open {
+		s := m.Open[slot]
+		fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="none" stroke="black" stroke-width="2"><title>%d migrating %s -> %s</title></rect>`+"\n",
+			(slot%svgCols)*cellSize, slot/svgCols*cellSize, cellSize, cellSize, slot, html.EscapeString(slotNodeName(s.Source)), html.EscapeString(slotNodeName(s.Target)))
+	}
+	for i, n := range m.Masters {
+		y := legendY + 20*i
+		fmt.Fprintf(w, `<rect x="0" y="%d" width="14" height="14" fill="%s"/><text x="20" y="%d">%s %d slots, %d ranges</text>`+"\n",
+			y, colors[n], y+12, html.EscapeString(n.Pod.Name), n.SlotsCount(), m.Fragments(n))
+	}
+	_, err := fmt.Fprintln(w, "</svg>")
+	return err
+}
+
+// WriteHTML wraps svg of slot map into a html page
+func (m *SlotMap) WriteHTML(w io.Writer, title string, cellSize int) error {
+	if _, err := fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title></head><body>\n<h3>%s</h3>\n",
+		html.EscapeString(title), html.EscapeString(title)); err != nil {
+		return err
+	}
+	if uncovered := m.Uncovered(); uncovered > 0 || len(m.Open) > 0 {
+		fmt.Fprintf(w, "<p>%d uncovered slots, %d open slots</p>\n", uncovered, len(m.Open))
+	}
+	if err := m.WriteSVG(w, cellSize); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "</body></html>")
+	return err
+}
+
+// slotNodeName returns pod name of node, "?" if node is unknown
+func slotNodeName(n *RedisNode) string {
+	if n == nil {
+		return "?"
+	}
+	if n.Pod == nil {
+		return n.ID
+	}
+	return n.Pod.Name
+}
@@ -1,8 +1,136 @@
 package redis
 
+import (
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/monsterxx03/kuberc/pkg/common"
+)
+
 type Slots struct {
 	Start int
 	End int
 	Master *RedisPod
 	Slaves []*RedisPod
 }
+
+// OpenSlot is a slot in migrating/importing state. Source or Target can be nil
+// if only one side of the migration is marked in cluster nodes.
+type OpenSlot struct {
+	Slot   int
+	Source *RedisNode
+	Target *RedisNode
+}
+
+// OpenSlots collects migrating/importing slots from nodes, merging both sides of a migration into one entry.
+func OpenSlots(nodes []*RedisNode) []*OpenSlot {
+	idMap := make(map[string]*RedisNode)
+	for _, n := range nodes {
+		idMap[n.ID] = n
+	}
+	type key struct {
+		slot           int
+		source, target string
+	}
+	m := make(map[key]*OpenSlot)
+	for _, n := range nodes {
+		for slot, target := range n.Migrating {
+			k := key{slot, n.ID, target}
+			if _, ok := m[k]; !ok {
+				m[k] = &OpenSlot{Slot: slot, Source: n, Target: idMap[target]}
+			}
+		}
+		for slot, source := range n.Importing {
+			k := key{slot, source, n.ID}
+			if _, ok := m[k]; !ok {
+				m[k] = &OpenSlot{Slot: slot, Source: idMap[source], Target: n}
+			}
+		}
+	}
+	result := make([]*OpenSlot, 0, len(m))
+	for _, s := range m {
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Slot < result[j].Slot
+	})
+	return result
+}
+
+// Shard is an entry of cluster shards (redis >= 7.0)
+type Shard struct {
+	Slots []SlotRange
+	Nodes []*ShardNode
+}
+
+type ShardNode struct {
+	ID                string
+	IP                string
+	Endpoint          string
+	Hostname          string
+	Port              int
+	TLSPort           int
+	Role              string
+	ReplicationOffset int64
+	Health            string
+	Pod               *corev1.Pod
+}
+
+func newShardNode(info map[string]interface{}, pods common.PodIndex) *ShardNode {
+	n := &ShardNode{
+		ID:       replyString(info["id"]),
+		IP:       replyString(info["ip"]),
+		Endpoint: replyString(info["endpoint"]),
+		Hostname: replyString(info["hostname"]),
+		Role:     replyString(info["role"]),
+		Health:   replyString(info["health"]),
+	}
+	if port, err := replyInt(info["port"]); err == nil {
+		n.Port = int(port)
+	}
+	if port, err := replyInt(info["tls-port"]); err == nil {
+		n.TLSPort = int(port)
+	}
+	n.ReplicationOffset, _ = replyInt(info["replication-offset"])
+	n.Pod, _ = pods.Find(n.IP, n.Hostname, n.Endpoint)
+	return n
+}
+
+const SlotsNum = 16384
+
+var crc16tab [256]uint16
+
+func init() {
+	// crc16 xmodem table, https://redis.io/topics/cluster-spec#keys-distribution-model
+	for i := 0; i < 256; i++ {
+		crc := uint16(i) << 8
+		for j := 0; j < 8; j++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+		crc16tab[i] = crc
+	}
+}
+
+func crc16(s string) uint16 {
+	var crc uint16
+	for i := 0; i < len(s); i++ {
+		crc = crc<<8 ^ crc16tab[byte(crc>>8)^s[i]]
+	}
+	return crc
+}
+
+// KeySlot returns slot of key, only hash tag in {} is hashed if it's not empty
+func KeySlot(key string) int {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			key = key[start+1 : start+1+end]
+		}
+	}
+	return int(crc16(key) % SlotsNum)
+}
@@ -0,0 +1,68 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type SlowlogEntry struct {
+	ID         int64
+	Time       time.Time
+	Duration   time.Duration
+	Args       []string
+	ClientAddr string
+	ClientName string
+}
+
+// SlowlogGet returns all entries in slowlog
+// https://redis.io/commands/slowlog
+func (r *RedisPod) SlowlogGet(ctx context.Context) ([]*SlowlogEntry, error) {
+	result, err := r.redisCliLocal(ctx, "slowlog get -1", false)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := parseReply(result)
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("wrong slowlog %s", result)
+	}
+	entries := make([]*SlowlogEntry, 0, len(items))
+	for _, item := range items {
+		fields, ok := item.([]interface{})
+		if !ok || len(fields) < 4 {
+			return nil, fmt.Errorf("wrong slowlog entry %v", item)
+		}
+		id, err := replyInt(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		ts, err := replyInt(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		us, err := replyInt(fields[2])
+		if err != nil {
+			return nil, err
+		}
+		e := &SlowlogEntry{ID: id, Time: time.Unix(ts, 0), Duration: time.Duration(us) * time.Microsecond}
+		args, _ := fields[3].([]interface{})
+		for _, a := range args {
+			e.Args = append(e.Args, replyString(a))
+		}
+		// client info is available since redis 4.0
+		if len(fields) >= 6 {
+			e.ClientAddr = replyString(fields[4])
+			e.ClientName = replyString(fields[5])
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+func (r *RedisPod) SlowlogReset(ctx context.Context) (string, error) {
+	return r.redisCliLocal(ctx, "slowlog reset", false)
+}
@@ -1,6 +1,7 @@
 package sentinel
 
 import (
+	"context"
 	"fmt"
 )
 
@@ -10,7 +11,7 @@ type MasterPod struct {
 	Slaves    []*SlavePod
 }
 
-func (m *MasterPod) PrettyPrint() {
+func (m *MasterPod) PrettyPrint(ctx context.Context) {
 	fmt.Println("Master Name:", m.Name)
 	fmt.Println("Master Pod:", m.Pod.Name)
 	fmt.Println("IP:", m.IP)
@@ -18,7 +19,7 @@ func (m *MasterPod) PrettyPrint() {
 	fmt.Println("Num Slaves", m.NumSlaves)
 	fmt.Println("Slaves:")
 	for _, s := range m.Slaves {
-		desc, err := s.GetDescription()
+		desc, err := s.GetDescription(ctx)
 		if err != nil {
 			panic(err)
 		}
@@ -26,6 +26,7 @@ type SentinelPod struct {
 	sentinelClient        *redis.SentinelClient
 	sentinelPortForwarder *common.PortForwarder
 	redisPort             int
+	conn                  *common.ConnConfig
 	clientset             *kubernetes.Clientset
 	restcfg               *restclient.Config
 	podsCache             []corev1.Pod
@@ -40,12 +41,13 @@ type RedisPod struct {
 	RoleReported  string
 	Flags         string
 	PortForwarder *common.PortForwarder
+	conn          *common.ConnConfig
 	clientset     *kubernetes.Clientset
 	restcfg       *restclient.Config
 }
 
-func NewRedisPod(podName, containerName, namespace string, port int, clientset *kubernetes.Clientset, restcfg *restclient.Config) (*RedisPod, error) {
-	pod, err := common.GetPod(podName, containerName, namespace, clientset, restcfg)
+func NewRedisPod(ctx context.Context, podName, containerName, namespace string, port int, conn *common.ConnConfig, clientset *kubernetes.Clientset, restcfg *restclient.Config) (*RedisPod, error) {
+	pod, err := common.GetPod(ctx, podName, containerName, namespace, clientset, restcfg)
 	if err != nil {
 		return nil, err
 	}
@@ -53,13 +55,14 @@ func NewRedisPod(podName, containerName, namespace string, port int, clientset *
 	r.IP = pod.Status.PodIP
 	r.Pod = pod
 	r.Port = port
+	r.conn = conn
 	r.clientset = clientset
 	r.restcfg = restcfg
 	return r, nil
 }
 
-func (r *RedisPod) IsSlave() (bool, error) {
-	result, err := r.execute("info replication")
+func (r *RedisPod) IsSlave(ctx context.Context) (bool, error) {
+	result, err := r.execute(ctx, "info replication")
 	if err != nil {
 		return false, err
 	}
@@ -67,24 +70,38 @@ func (r *RedisPod) IsSlave() (bool, error) {
 	return m["role"] == "slave", nil
 }
 
-func (r *RedisPod) execute(cmd string) (string, error) {
-	cmd = fmt.Sprintf("redis-cli -p %d %s", r.Port, cmd)
-	result, err := common.Execute(r.clientset, r.restcfg, &common.ExecTarget{Pod: r.Pod, Container: r.ContainerName}, cmd, false, false)
+// Role returns "master" or "slave of <ip>:<port>" from info replication
+func (r *RedisPod) Role(ctx context.Context) (string, error) {
+	result, err := r.execute(ctx, "info replication")
+	if err != nil {
+		return "", err
+	}
+	m := parseRedisInfo(result)
+	if m["role"] == "slave" {
+		return fmt.Sprintf("slave of %s:%s", m["master_host"], m["master_port"]), nil
+	}
+	return m["role"], nil
+}
+
+func (r *RedisPod) execute(ctx context.Context, cmd string) (string, error) {
+	// force formatted output, exec runs without tty when password is passed
+	cmd = fmt.Sprintf("redis-cli --no-raw -p %d %s %s", r.Port, r.conn.CliArgs(), cmd)
+	result, err := common.Execute(ctx, r.clientset, r.restcfg, &common.ExecTarget{Pod: r.Pod, Container: r.ContainerName, Env: r.conn.CliEnv()}, cmd, false, false)
 	if err != nil {
 		return "", err
 	}
 	return result, nil
 }
 
-func NewSentinelPod(sentinelPodName string, sentinelContainerName string, namespace string, sentinelPort, redisPort int, clientset *kubernetes.Clientset, restcfg *restclient.Config) (*SentinelPod, error) {
-	pod, err := common.GetPod(sentinelPodName, sentinelContainerName, namespace, clientset, restcfg)
+func NewSentinelPod(ctx context.Context, sentinelPodName string, sentinelContainerName string, namespace string, sentinelPort, redisPort int, conn *common.ConnConfig, clientset *kubernetes.Clientset, restcfg *restclient.Config) (*SentinelPod, error) {
+	pod, err := common.GetPod(ctx, sentinelPodName, sentinelContainerName, namespace, clientset, restcfg)
 	if err != nil {
 		return nil, err
 	}
 	forwarder := common.NewPortForwarder(clientset, restcfg, pod, sentinelPort, sentinelPort)
 	return &SentinelPod{pod: pod, sentinelContainerName: sentinelContainerName, sentinelPort: sentinelPort,
-		sentinelClient:        redis.NewSentinelClient(&redis.Options{Addr: fmt.Sprintf("localhost:%d", sentinelPort)}),
-		sentinelPortForwarder: forwarder, redisPort: redisPort,
+		sentinelClient:        redis.NewSentinelClient(conn.ApplyTo(&redis.Options{Addr: fmt.Sprintf("localhost:%d", sentinelPort)})),
+		sentinelPortForwarder: forwarder, redisPort: redisPort, conn: conn,
 		clientset: clientset, restcfg: restcfg}, nil
 }
 
@@ -92,17 +109,17 @@ func (s *SentinelPod) Info() error {
 	return nil
 }
 
-func (s *SentinelPod) Masters() error {
-	if err := s.sentinelPortForwarder.Start(); err != nil {
+func (s *SentinelPod) Masters(ctx context.Context) error {
+	if err := s.sentinelPortForwarder.Start(ctx); err != nil {
 		return err
 	}
 	defer s.sentinelPortForwarder.Stop()
 
-	result, err := s.sentinelClient.Masters(context.Background()).Result()
+	result, err := s.sentinelClient.Masters(ctx).Result()
 	if err != nil {
 		return err
 	}
-	masters, err := s.parseMasters(result)
+	masters, err := s.parseMasters(ctx, result)
 	if err != nil {
 		return err
 	}
@@ -115,18 +132,17 @@ func (s *SentinelPod) Masters() error {
 	return nil
 }
 
-func (s *SentinelPod) Master(name string) error {
-	if err := s.sentinelPortForwarder.Start(); err != nil {
+func (s *SentinelPod) Master(ctx context.Context, name string) error {
+	if err := s.sentinelPortForwarder.Start(ctx); err != nil {
 		return err
 	}
 	defer s.sentinelPortForwarder.Stop()
 
-	ctx := context.Background()
 	mres, err := s.sentinelClient.Master(ctx, name).Result()
 	if err != nil {
 		return err
 	}
-	m, err := s.newMasterPod(mres)
+	m, err := s.newMasterPod(ctx, mres)
 	if err != nil {
 		return err
 	}
@@ -134,21 +150,20 @@ func (s *SentinelPod) Master(name string) error {
 	if err != nil {
 		return err
 	}
-	slaves, err := s.parseSlaves(sres)
+	slaves, err := s.parseSlaves(ctx, sres)
 	if err != nil {
 		return err
 	}
 	m.Slaves = slaves
-	m.PrettyPrint()
+	m.PrettyPrint(ctx)
 	return nil
 }
 
-func (s *SentinelPod) Failover(name string) error {
-	if err := s.sentinelPortForwarder.Start(); err != nil {
+func (s *SentinelPod) Failover(ctx context.Context, name string) error {
+	if err := s.sentinelPortForwarder.Start(ctx); err != nil {
 		return err
 	}
 	defer s.sentinelPortForwarder.Stop()
-	ctx := context.Background()
 	res, err := s.sentinelClient.Failover(ctx, name).Result()
 	if err != nil {
 		return err
@@ -157,15 +172,50 @@ func (s *SentinelPod) Failover(name string) error {
 	return nil
 }
 
+// MasterNames returns names of masters monitored by sentinel
+func (s *SentinelPod) MasterNames(ctx context.Context) ([]string, error) {
+	res, err := s.cli(ctx, "sentinel masters", true)
+	if err != nil {
+		return nil, err
+	}
+	// raw output flattens field/value pairs of every master into lines
+	lines := strings.Split(res, "\n")
+	names := make([]string, 0)
+	for i := 0; i+1 < len(lines); i++ {
+		if strings.TrimSpace(lines[i]) == "name" {
+			names = append(names, strings.TrimSpace(lines[i+1]))
+			i++
+		}
+	}
+	return names, nil
+}
+
+// MasterPodName returns pod of master name monitored by sentinel
+func (s *SentinelPod) MasterPodName(ctx context.Context, name string) (string, error) {
+	res, err := s.cli(ctx, "sentinel get-master-addr-by-name "+name, true)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(res)
+	if len(fields) != 2 {
+		return "", fmt.Errorf("can't get master addr of %s: %s", name, strings.TrimSpace(res))
+	}
+	pod, err := s.getPodByIP(ctx, fields[0])
+	if err != nil {
+		return "", err
+	}
+	return pod.Name, nil
+}
+
 func (s *SentinelPod) Check(name string) error {
 	return nil
 }
 
-func (s *SentinelPod) getPodsInNamespace(namespace string) ([]corev1.Pod, error) {
+func (s *SentinelPod) getPodsInNamespace(ctx context.Context, namespace string) ([]corev1.Pod, error) {
 	if s.podsCache != nil {
 		return s.podsCache, nil
 	}
-	pods, err := s.clientset.CoreV1().Pods(namespace).List(context.Background(), metav1.ListOptions{})
+	pods, err := s.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
@@ -173,41 +223,41 @@ func (s *SentinelPod) getPodsInNamespace(namespace string) ([]corev1.Pod, error)
 	return s.podsCache, nil
 }
 
-func (s *SentinelPod) getPodByIP(ip string) (*corev1.Pod, error) {
-	pods, err := s.getPodsInNamespace(s.pod.Namespace)
+func (s *SentinelPod) getPodByIP(ctx context.Context, ip string) (*corev1.Pod, error) {
+	pods, err := s.getPodsInNamespace(ctx, s.pod.Namespace)
 	if err != nil {
 		return nil, err
 	}
-	for _, pod := range pods {
-		if pod.Status.PodIP == ip {
-			return &pod, nil
-		}
+	// sentinel reports hostname instead of ip when announce-hostnames is enabled
+	if pod, ok := common.NewPodIndex(pods).Find(ip); ok {
+		return pod, nil
 	}
 	return nil, fmt.Errorf("can't find pod with ip %s", ip)
 }
 
-func (s *SentinelPod) cli(cmd string, raw bool) (string, error) {
+func (s *SentinelPod) cli(ctx context.Context, cmd string, raw bool) (string, error) {
 	var c string
 	if raw {
-		c = fmt.Sprintf("redis-cli --raw -p %d %s", s.sentinelPort, cmd)
+		c = fmt.Sprintf("redis-cli --raw -p %d %s %s", s.sentinelPort, s.conn.CliArgs(), cmd)
 	} else {
-		c = fmt.Sprintf("redis-cli -p %d %s", s.sentinelPort, cmd)
+		// force formatted output, exec runs without tty when password is passed
+		c = fmt.Sprintf("redis-cli --no-raw -p %d %s %s", s.sentinelPort, s.conn.CliArgs(), cmd)
 	}
-	return s.execute(c)
+	return s.execute(ctx, c)
 }
 
-func (s *SentinelPod) execute(cmd string) (string, error) {
-	result, err := common.Execute(s.clientset, s.restcfg, &common.ExecTarget{Pod: s.pod, Container: s.sentinelContainerName}, cmd, false, false)
+func (s *SentinelPod) execute(ctx context.Context, cmd string) (string, error) {
+	result, err := common.Execute(ctx, s.clientset, s.restcfg, &common.ExecTarget{Pod: s.pod, Container: s.sentinelContainerName, Env: s.conn.CliEnv()}, cmd, false, false)
 	if err != nil {
 		return "", err
 	}
 	return result, nil
 }
 
-func (s *SentinelPod) parseMasters(result []interface{}) ([]*MasterPod, error) {
+func (s *SentinelPod) parseMasters(ctx context.Context, result []interface{}) ([]*MasterPod, error) {
 	masters := make([]*MasterPod, 0, len(result))
 	for _, item := range parseSentinelSliceResult(result) {
-		m, err := s.newMasterPod(item)
+		m, err := s.newMasterPod(ctx, item)
 		if err != nil {
 			return nil, err
 		}
@@ -216,10 +266,10 @@ func (s *SentinelPod) parseMasters(result []interface{}) ([]*MasterPod, error) {
 	return masters, nil
 }
 
-func (s *SentinelPod) parseSlaves(result []interface{}) ([]*SlavePod, error) {
+func (s *SentinelPod) parseSlaves(ctx context.Context, result []interface{}) ([]*SlavePod, error) {
 	slaves := make([]*SlavePod, 0, len(result))
 	for _, item := range parseSentinelSliceResult(result) {
-		slave, err := s.newSlavePod(item)
+		slave, err := s.newSlavePod(ctx, item)
 		if err != nil {
 			return nil, err
 		}
@@ -228,15 +278,16 @@ func (s *SentinelPod) parseSlaves(result []interface{}) ([]*SlavePod, error) {
 	return slaves, nil
 }
 
-func (s *SentinelPod) newSlavePod(result map[string]string) (slave *SlavePod, err error) {
+func (s *SentinelPod) newSlavePod(ctx context.Context, result map[string]string) (slave *SlavePod, err error) {
 	slave = new(SlavePod)
 	slave.Port = s.redisPort
+	slave.conn = s.conn
 	slave.Name = result["name"]
 	slave.IP = result["ip"]
 	slave.Flags = result["flags"]
 	slave.RoleReported = result["role-reported"]
 
-	pod, err := s.getPodByIP(slave.IP)
+	pod, err := s.getPodByIP(ctx, slave.IP)
 	if err != nil {
 		klog.Error(err)
 		return slave, nil
@@ -246,14 +297,15 @@ func (s *SentinelPod) newSlavePod(result map[string]string) (slave *SlavePod, er
 	return
 }
 
-func (s *SentinelPod) newMasterPod(result map[string]string) (master *MasterPod, err error) {
+func (s *SentinelPod) newMasterPod(ctx context.Context, result map[string]string) (master *MasterPod, err error) {
 	master = new(MasterPod)
+	master.conn = s.conn
 	master.Name = result["name"]
 	master.IP = result["ip"]
 	master.Flags = result["flags"]
 	master.RoleReported = result["role-reported"]
 	master.NumSlaves, err = strconv.Atoi(result["num-slaves"])
-	pod, err := s.getPodByIP(master.IP)
+	pod, err := s.getPodByIP(ctx, master.IP)
 	if err != nil {
 		klog.Error(err)
 		return master, nil
@@ -291,30 +343,30 @@ func parseRedisInfo(result string) (info map[string]string) {
 	return
 }
 
-func Sync(slavePodName, masterPodName, containerName, namespace string, port int, clientset *kubernetes.Clientset, restcfg *restclient.Config, wait bool) error {
-	master, err := NewRedisPod(masterPodName, containerName, namespace, port, clientset, restcfg)
+func Sync(ctx context.Context, slavePodName, masterPodName, containerName, namespace string, port int, conn *common.ConnConfig, clientset *kubernetes.Clientset, restcfg *restclient.Config, wait bool) error {
+	master, err := NewRedisPod(ctx, masterPodName, containerName, namespace, port, conn, clientset, restcfg)
 	if err != nil {
 		return err
 	}
-	slave, err := NewRedisPod(slavePodName, containerName, namespace, port, clientset, restcfg)
+	slave, err := NewRedisPod(ctx, slavePodName, containerName, namespace, port, conn, clientset, restcfg)
 	if err != nil {
 		return err
 	}
-	isSlave, err := master.IsSlave()
+	isSlave, err := master.IsSlave(ctx)
 	if err != nil {
 		return err
 	}
 	if isSlave {
 		return fmt.Errorf("target master pod %s's role is slave", masterPodName)
 	}
-	isSlave, err = slave.IsSlave()
+	isSlave, err = slave.IsSlave(ctx)
 	if err != nil {
 		return err
 	}
 	if isSlave {
 		return fmt.Errorf("target slave pod %s's role is already slave", slavePodName)
 	}
-	r, err := slave.execute(fmt.Sprintf("replicaof %s %d", master.IP, master.Port))
+	r, err := slave.execute(ctx, fmt.Sprintf("replicaof %s %d", master.IP, master.Port))
 	if err != nil {
 		return err
 	}
@@ -322,39 +374,16 @@ func Sync(slavePodName, masterPodName, containerName, namespace string, port int
 	return nil
 }
 
-func podIsReady(pod corev1.Pod) bool {
-	if pod.Status.Phase != "Running" {
-		return false
-	}
-	for _, s := range pod.Status.ContainerStatuses {
-		if !*s.Started || !s.Ready {
-			return false
-		}
-	}
-	return true
-}
-
-func Restart(sentinelStsName, namespace string, clientset *kubernetes.Clientset, restcfg *restclient.Config) error {
-	ctx := context.Background()
-	sts, err := clientset.AppsV1().StatefulSets(namespace).Get(context.Background(), sentinelStsName, metav1.GetOptions{})
-	if err != nil {
-		return err
-	}
-	selector := make([]string, 0, len(sts.Spec.Selector.MatchLabels))
-	for k, v := range sts.Spec.Selector.MatchLabels {
-		selector = append(selector, fmt.Sprintf("%s=%s", k, v))
-	}
-	listOpts := metav1.ListOptions{LabelSelector: strings.Join(selector, ",")}
-	r, err := clientset.CoreV1().Pods(namespace).List(ctx, listOpts)
+func Restart(ctx context.Context, sentinelStsName, namespace string, clientset *kubernetes.Clientset, restcfg *restclient.Config) error {
+	pods, err := common.StatefulSetPods(ctx, clientset, namespace, sentinelStsName)
 	if err != nil {
 		return err
 	}
-	pods := r.Items
 	if len(pods) < 3 {
 		return fmt.Errorf("sts %s pods num(%d) < 3, is it a sentinel sts?", sentinelStsName, len(pods))
 	}
 	for _, pod := range pods {
-		if !podIsReady(pod) {
+		if !common.PodIsReady(pod) {
 			return fmt.Errorf("pod %s is not ready", pod.Name)
 		}
 	}
@@ -363,21 +392,27 @@ func Restart(sentinelStsName, namespace string, clientset *kubernetes.Clientset,
 		if err := clientset.CoreV1().Pods(namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
 			return err
 		}
-		err := wait.Poll(5*time.Second, 1*time.Minute, func() (bool, error) {
+		pollCtx, cancel := context.WithTimeout(ctx, 1*time.Minute)
+		err := wait.PollUntil(5*time.Second, func() (bool, error) {
 			p, err := clientset.CoreV1().Pods(namespace).Get(ctx, pod.Name, metav1.GetOptions{})
 			if err != nil {
 				return false, err
 			}
-			if podIsReady(*p) {
+			if common.PodIsReady(*p) {
 				return true, nil
 			}
 			return false, nil
-		})
+		}, pollCtx.Done())
+		cancel()
 		if err != nil {
 			return err
 		}
 		fmt.Println(pod.Name + " is ready")
-		time.Sleep(5 * time.Second)
+		select {
+		case <-time.After(5 * time.Second):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 	return nil
 }
@@ -13,24 +13,24 @@ type SlavePod struct {
 	RedisPod
 }
 
-func (s *SlavePod) GetSyncStatus() (slaveSyncInfo, error) {
+func (s *SlavePod) GetSyncStatus(ctx context.Context) (slaveSyncInfo, error) {
 	if s.PortForwarder == nil {
 		return make(slaveSyncInfo), nil
 	}
-	if err := s.PortForwarder.Start(); err != nil {
+	if err := s.PortForwarder.Start(ctx); err != nil {
 		return nil, err
 	}
 	defer s.PortForwarder.Stop()
-	client := redis.NewClient(&redis.Options{Addr: fmt.Sprintf("localhost:%d", s.Port)})
-	result, err := client.Info(context.Background(), "replication").Result()
+	client := redis.NewClient(s.conn.ApplyTo(&redis.Options{Addr: fmt.Sprintf("localhost:%d", s.Port)}))
+	result, err := client.Info(ctx, "replication").Result()
 	if err != nil {
 		return nil, err
 	}
 	return parseRedisInfo(result), nil
 }
 
-func (s *SlavePod) GetDescription() (string, error) {
-	info, err := s.GetSyncStatus()
+func (s *SlavePod) GetDescription(ctx context.Context) (string, error) {
+	info, err := s.GetSyncStatus(ctx)
 	if err != nil {
 		return "", err
 	}